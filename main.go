@@ -1,7 +1,6 @@
 package main
 
 import (
-	"log"
 	"os"
 
 	"github.com/warehouse-13/hammertime/pkg/command"
@@ -11,6 +10,6 @@ func main() {
 	app := command.NewApp(os.Stdout)
 
 	if err := app.Run(os.Args); err != nil {
-		log.Fatal(err)
+		os.Exit(command.HandleError(app, err))
 	}
 }
@@ -7,16 +7,20 @@ import (
 	"github.com/weaveworks-liquidmetal/flintlock/api/types"
 	"google.golang.org/grpc"
 	"google.golang.org/protobuf/types/known/emptypb"
-	"k8s.io/utils/pointer"
 
-	"github.com/warehouse-13/hammertime/pkg/dialler"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
 )
 
 //go:generate go run github.com/maxbrunsfeld/counterfeiter/v6 -generate
 
-// Client is a wrapper around a v1alpha1.MicroVMClient.
+// Client adapts an sdk.Client to the FlintlockClient the commands use.
 type Client struct {
+	SDK *sdk.Client
+
+	// Deprecated: use SDK, which makes the same calls with a context and load
+	// balances reads. MicroVMClient calls the server over Conn.
 	v1alpha1.MicroVMClient
+	// Deprecated: use SDK.Conn.
 	Conn *grpc.ClientConn
 }
 
@@ -29,53 +33,77 @@ type FlintlockClient interface {
 	Close() error
 }
 
-// New returns a new flintlock Client.
-func New(address, basicAuthToken string) (FlintlockClient, error) {
-	conn, err := dialler.New(address, basicAuthToken, nil)
+// New returns a new flintlock Client, configured by opts as sdk.New is. If the
+// address resolves to several servers (see dialler.IsLoadBalanced), reads are
+// spread across all of them.
+func New(address, basicAuthToken string, opts ...sdk.Option) (FlintlockClient, error) {
+	client, err := sdk.New(address, append([]sdk.Option{sdk.WithToken(basicAuthToken)}, opts...)...)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Client{v1alpha1.NewMicroVMClient(conn), conn}, nil
+	return &Client{
+		SDK:           client,
+		MicroVMClient: v1alpha1.NewMicroVMClient(client.Conn()),
+		Conn:          client.Conn(),
+	}, nil
 }
 
 func (c *Client) Close() error {
-	return c.Conn.Close()
+	return c.SDK.Close()
 }
 
 // Create creates a new Microvm with the MicroVMClient.
 func (c *Client) Create(mvm *types.MicroVMSpec) (*v1alpha1.CreateMicroVMResponse, error) {
-	createReq := v1alpha1.CreateMicroVMRequest{
-		Microvm: mvm,
+	created, err := c.SDK.Create(context.Background(), mvm)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.CreateMicroVM(context.Background(), &createReq)
+	return &v1alpha1.CreateMicroVMResponse{Microvm: created}, nil
 }
 
 // Get fetches a Microvm with the MicroVMClient by the given ID.
 func (c *Client) Get(uid string) (*v1alpha1.GetMicroVMResponse, error) {
-	getReq := v1alpha1.GetMicroVMRequest{
-		Uid: uid,
+	mvm, err := c.SDK.Get(context.Background(), uid)
+	if err != nil {
+		return nil, err
 	}
 
-	return c.GetMicroVM(context.Background(), &getReq)
+	return &v1alpha1.GetMicroVMResponse{Microvm: mvm}, nil
 }
 
 // List fetches Microvms filtered by name and namespace.
 func (c *Client) List(name, ns string) (*v1alpha1.ListMicroVMsResponse, error) {
-	listReq := v1alpha1.ListMicroVMsRequest{
-		Namespace: ns,
-		Name:      pointer.String(name),
+	mvms, err := c.SDK.List(context.Background(), ns, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &v1alpha1.ListMicroVMsResponse{Microvm: mvms}, nil
+}
+
+// ListStream fetches the same Microvms as List, over the streaming RPC.
+func (c *Client) ListStream(name, ns string) (*v1alpha1.ListMicroVMsResponse, error) {
+	res := &v1alpha1.ListMicroVMsResponse{}
+
+	err := c.SDK.ListStream(context.Background(), ns, name, func(mvm *types.MicroVM) error {
+		res.Microvm = append(res.Microvm, mvm)
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	return c.ListMicroVMs(context.Background(), &listReq)
+	return res, nil
 }
 
 // Delete deletes a Microvm by the given id.
 func (c *Client) Delete(uid string) (*emptypb.Empty, error) {
-	delReq := v1alpha1.DeleteMicroVMRequest{
-		Uid: uid,
+	if err := c.SDK.Delete(context.Background(), uid); err != nil {
+		return nil, err
 	}
 
-	return c.DeleteMicroVM(context.Background(), &delReq)
+	return &emptypb.Empty{}, nil
 }
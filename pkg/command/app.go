@@ -4,8 +4,13 @@ import (
 	"io"
 
 	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/flags"
 )
 
+const errorFormatKey = "error-format"
+
 // NewApp is a builder which returns a cli.App.
 func NewApp(out io.Writer) *cli.App {
 	app := cli.NewApp()
@@ -19,16 +24,49 @@ func NewApp(out io.Writer) *cli.App {
 	app.Usage = "a basic cli client to flintlock"
 	app.EnableBashCompletion = true
 	app.Commands = commands()
+	app.Flags = flags.CLIFlags(
+		flags.WithErrorFormatFlag(),
+	)
+	// Errors are written by HandleError once Run returns, rather than by
+	// urfave/cli, so only note the requested format here.
+	app.ExitErrHandler = func(ctx *cli.Context, _ error) {
+		if format := ctx.String(errorFormatKey); format != "" {
+			ctx.App.Metadata[errorFormatKey] = format
+		}
+	}
+	app.Metadata = map[string]interface{}{}
 
 	return app
 }
 
+// HandleError writes an error returned by the app's Run to the app's
+// ErrWriter, in the format set with --error-format, and returns the code to
+// exit with. See the exitcode package for the codes.
+func HandleError(app *cli.App, err error) int {
+	if err == nil {
+		return exitcode.OK
+	}
+
+	format, _ := app.Metadata[errorFormatKey].(string)
+
+	return exitcode.Write(app.ErrWriter, err, format)
+}
+
 func commands() []*cli.Command {
 	return []*cli.Command{
 		createCommand(),
 		getCommand(),
 		listCommand(),
 		deleteCommand(),
+		sshCommand(),
+		pingCommand(),
+		uiCommand(),
+		statsCommand(),
+		exporterCommand(),
+		serveCommand(),
+		completionCommand(),
+		renderCommand(),
+		presetCommand(),
 		versionCommand(),
 	}
 }
@@ -15,7 +15,8 @@ import (
 	"github.com/warehouse-13/hammertime/pkg/client"
 	"github.com/warehouse-13/hammertime/pkg/command"
 	"github.com/warehouse-13/hammertime/pkg/config"
-	"github.com/warehouse-13/hammertime/pkg/dialler"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
 	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
@@ -50,6 +51,30 @@ func Test_CRUD_noBasicAuth_noTLS(t *testing.T) {
 	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
 }
 
+func Test_Client_deprecatedFields(t *testing.T) {
+	g := NewWithT(t)
+
+	fakeserver := safety.New()
+	dialer := fakeserver.StartBuf("")
+
+	t.Cleanup(func() {
+		fakeserver.Stop()
+	})
+
+	c, err := cl(dialer)("", "")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	defer c.Close()
+
+	// Callers of the previous Client can still reach the server through it
+	legacy, ok := c.(*client.Client)
+	g.Expect(ok).To(BeTrue())
+	g.Expect(legacy.Conn).To(BeIdenticalTo(legacy.SDK.Conn()))
+
+	_, err = legacy.ListMicroVMs(context.Background(), &v1alpha1.ListMicroVMsRequest{Namespace: "ns"})
+	g.Expect(err).NotTo(HaveOccurred())
+}
+
 func Test_CRUD_basicAuth_noTLS(t *testing.T) {
 	g := NewWithT(t)
 
@@ -109,17 +134,28 @@ func Test_basicAuth_failsWithNoClientToken(t *testing.T) {
 	g.Expect(command.CreateFn(w, cfg)).To(MatchError(ContainSubstring("unauthenticated")))
 }
 
-func cl(dialer func(context.Context, string) (net.Conn, error)) func(string, string) (client.FlintlockClient, error) {
-	return func(string, token string) (client.FlintlockClient, error) {
-		opt := []grpc.DialOption{grpc.WithContextDialer(dialer)}
-		conn, err := dialler.New("bufnet", token, opt)
-		if err != nil {
-			return nil, err
-		}
-
-		return &client.Client{
-			MicroVMClient: v1alpha1.NewMicroVMClient(conn),
-			Conn:          conn,
-		}, nil
+func cl(dialer func(context.Context, string) (net.Conn, error)) clientBuilderFunc {
+	return func(_ string, token string, opts ...sdk.Option) (client.FlintlockClient, error) {
+		return client.New("bufnet:9090", token, append(opts, sdk.WithDialOptions(grpc.WithContextDialer(dialer)))...)
 	}
 }
+
+func Test_HandleError(t *testing.T) {
+	g := NewWithT(t)
+
+	errBuf := &bytes.Buffer{}
+
+	app := command.NewApp(&bytes.Buffer{})
+	app.ErrWriter = errBuf
+
+	err := app.Run([]string{"hammertime", "--error-format", "json", "render"})
+	g.Expect(err).To(HaveOccurred())
+	g.Expect(command.HandleError(app, err)).To(Equal(exitcode.Usage))
+	g.Expect(errBuf.String()).To(Equal(`{"code":2,"message":"required: --file"}` + "\n"))
+}
+
+func Test_HandleError_noError(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(command.HandleError(command.NewApp(nil), nil)).To(Equal(exitcode.OK))
+}
@@ -0,0 +1,273 @@
+package command
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+const (
+	shellBash = "bash"
+	shellZsh  = "zsh"
+	shellFish = "fish"
+
+	completeFlag = "--generate-bash-completion"
+)
+
+// The scripts ask hammertime for completions by re-running the command line
+// typed so far with --generate-bash-completion. When hammertime has nothing
+// to offer (eg. for --file in a subdirectory) they fall back to file names.
+var completionScripts = map[string]string{
+	shellBash: `# bash completion for hammertime
+_hammertime_complete() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  if [[ "$cur" == "-"* ]]; then
+    opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" "$cur" ` + completeFlag + ` 2>/dev/null )
+  else
+    opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" ` + completeFlag + ` 2>/dev/null )
+  fi
+  COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
+  return 0
+}
+
+complete -o bashdefault -o default -F _hammertime_complete hammertime
+`,
+	shellZsh: `#compdef hammertime
+
+_hammertime_complete() {
+  local -a opts
+  local cur
+  cur=${words[-1]}
+  if [[ "$cur" == "-"* ]]; then
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ${cur} ` + completeFlag + ` 2>/dev/null)}")
+  else
+    opts=("${(@f)$(${words[@]:0:#words[@]-1} ` + completeFlag + ` 2>/dev/null)}")
+  fi
+
+  if [[ "${opts[1]}" != "" ]]; then
+    _describe 'values' opts
+  else
+    _files
+  fi
+}
+
+compdef _hammertime_complete hammertime
+`,
+	shellFish: `# fish completion for hammertime
+function __hammertime_complete
+    set -l args (commandline -opc)
+    set -l cur (commandline -ct)
+    set -l opts
+    if string match -q -- '-*' $cur
+        set opts ($args $cur ` + completeFlag + ` 2>/dev/null)
+    else
+        set opts ($args ` + completeFlag + ` 2>/dev/null)
+    end
+
+    if test (count $opts) -eq 0
+        __fish_complete_path $cur
+    else
+        printf '%s\n' $opts
+    end
+end
+
+complete -c hammertime -f -a '(__hammertime_complete)'
+`,
+}
+
+// The flags whose values can be completed, by name and alias.
+var completableFlags = map[string]string{
+	"namespace": "namespace",
+	"ns":        "namespace",
+	"name":      "name",
+	"n":         "name",
+	"id":        "id",
+	"i":         "id",
+	"file":      "file",
+	"f":         "file",
+}
+
+var specExtensions = []string{".json", ".yaml", ".yml"}
+
+func completionCommand() *cli.Command {
+	cfg := &config.Config{}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:      "completion",
+		Usage:     "print a shell completion script, eg. `source <(hammertime completion bash)`",
+		ArgsUsage: "bash|zsh|fish",
+		Before:    flags.ParseFlags(cfg),
+		Action: func(c *cli.Context) error {
+			return CompletionFn(w, cfg)
+		},
+	}
+}
+
+// CompletionFn prints the completion script for the shell given as the first
+// argument.
+func CompletionFn(w utils.Writer, cfg *config.Config) error {
+	if len(cfg.Args) == 0 {
+		return exitcode.New(exitcode.Usage, "required: bash, zsh or fish")
+	}
+
+	script, ok := completionScripts[cfg.Args[0]]
+	if !ok {
+		return exitcode.New(exitcode.Usage, "unsupported shell %q, expected bash, zsh or fish", cfg.Args[0])
+	}
+
+	w.Printf("%s", script)
+
+	return nil
+}
+
+// completeFlags returns a BashCompleteFunc which completes the values of
+// --namespace, --name and --id from the Microvms on the server, and --file
+// from the spec files in the working directory. The server is found from the
+// flags and environment already given, as it would be for the command itself,
+// but the Microvms are only narrowed by a --namespace or --name actually given.
+// Anything else falls back to completing flag names.
+func completeFlags(cfg *config.Config) cli.BashCompleteFunc {
+	return func(ctx *cli.Context) {
+		flag := completingFlag(os.Args)
+		if flag == "" {
+			cli.DefaultCompleteWithFlags(ctx.Command)(ctx)
+
+			return
+		}
+
+		// Nothing useful can be shown for a bad command line, so errors are
+		// swallowed and the shell falls back to its default.
+		if err := flags.ParseFlags(cfg)(ctx); err != nil {
+			return
+		}
+
+		// Better to show nothing than to keep the user waiting
+		cfg.Retries = 0
+
+		// Only what the user gave narrows the list, not the defaults
+		if !ctx.IsSet("name") {
+			cfg.MvmName = ""
+		}
+
+		if !ctx.IsSet("namespace") {
+			cfg.MvmNamespace = ""
+		}
+
+		_ = CompleteFn(utils.NewWriter(ctx.App.Writer), cfg, flag)
+	}
+}
+
+// completingFlag returns the flag whose value is being completed, if any. It
+// is the argument before the completion flag.
+func completingFlag(args []string) string {
+	if len(args) < 2 || args[len(args)-1] != completeFlag { //nolint: gomnd // the flag and the one before it
+		return ""
+	}
+
+	prev := args[len(args)-2]
+	if !strings.HasPrefix(prev, "-") {
+		return ""
+	}
+
+	return completableFlags[strings.TrimLeft(prev, "-")]
+}
+
+// CompleteFn prints the possible values of the flag, one per line: namespaces,
+// names or UIDs of the Microvms on the server (narrowed by any --namespace and
+// --name already given), or spec files for `file`.
+func CompleteFn(w utils.Writer, cfg *config.Config, flag string) error {
+	if flag == "file" {
+		return completeFiles(w)
+	}
+
+	client, err := cfg.ClientBuilderFunc(
+		cfg.GRPCAddress, cfg.Token, append(cfg.SDKOptions(), sdk.WithCallTimeout(defaults.CompletionTimeout))...,
+	)
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	name, namespace := cfg.MvmName, cfg.MvmNamespace
+
+	switch flag {
+	case "namespace":
+		name, namespace = "", ""
+	case "name":
+		name = ""
+	}
+
+	res, err := client.List(name, namespace)
+	if err != nil {
+		return err
+	}
+
+	values := map[string]bool{}
+
+	for _, mvm := range res.Microvm {
+		switch flag {
+		case "namespace":
+			values[mvm.Spec.Namespace] = true
+		case "name":
+			values[mvm.Spec.Id] = true
+		case "id":
+			values[mvm.Spec.GetUid()] = true
+		}
+	}
+
+	printSorted(w, values)
+
+	return nil
+}
+
+func completeFiles(w utils.Writer) error {
+	entries, err := os.ReadDir(".")
+	if err != nil {
+		return err
+	}
+
+	values := map[string]bool{}
+
+	for _, entry := range entries {
+		for _, ext := range specExtensions {
+			if !entry.IsDir() && filepath.Ext(entry.Name()) == ext {
+				values[entry.Name()] = true
+			}
+		}
+	}
+
+	printSorted(w, values)
+
+	return nil
+}
+
+func printSorted(w utils.Writer, values map[string]bool) {
+	sorted := make([]string, 0, len(values))
+
+	for value := range values {
+		if utils.IsSet(value) {
+			sorted = append(sorted, value)
+		}
+	}
+
+	sort.Strings(sorted)
+
+	for _, value := range sorted {
+		w.Print(value)
+	}
+}
@@ -0,0 +1,212 @@
+package command_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v2"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"k8s.io/utils/pointer"
+
+	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
+	"github.com/warehouse-13/hammertime/pkg/command"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_CompleteFn(t *testing.T) {
+	mvms := &v1alpha1.ListMicroVMsResponse{
+		Microvm: []*types.MicroVM{
+			{Spec: &types.MicroVMSpec{Id: "b", Namespace: "ns1", Uid: pointer.String("uid-2")}},
+			{Spec: &types.MicroVMSpec{Id: "a", Namespace: "ns1", Uid: pointer.String("uid-1")}},
+			{Spec: &types.MicroVMSpec{Id: "a", Namespace: "ns2", Uid: pointer.String("uid-3")}},
+		},
+	}
+
+	tt := []struct {
+		name     string
+		flag     string
+		cfg      config.Config
+		expected string
+		listName string
+		listNs   string
+	}{
+		{
+			name:     "namespaces are listed across all namespaces",
+			flag:     "namespace",
+			cfg:      config.Config{MvmNamespace: "ns1", MvmName: "a"},
+			expected: "ns1\nns2\n",
+		},
+		{
+			name:     "names are listed within the namespace",
+			flag:     "name",
+			cfg:      config.Config{MvmNamespace: "ns1", MvmName: "a"},
+			expected: "a\nb\n",
+			listNs:   "ns1",
+		},
+		{
+			name:     "ids are listed within the namespace and name",
+			flag:     "id",
+			cfg:      config.Config{MvmNamespace: "ns1", MvmName: "a"},
+			expected: "uid-1\nuid-2\nuid-3\n",
+			listName: "a",
+			listNs:   "ns1",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mockClient := new(fakeclient.FakeFlintlockClient)
+			mockClient.ListReturns(mvms, nil)
+
+			cfg := tc.cfg
+			cfg.ClientBuilderFunc = testClient(mockClient, nil)
+
+			buf := &bytes.Buffer{}
+			g.Expect(command.CompleteFn(utils.NewWriter(buf), &cfg, tc.flag)).To(Succeed())
+			g.Expect(buf.String()).To(Equal(tc.expected))
+
+			name, ns := mockClient.ListArgsForCall(0)
+			g.Expect(name).To(Equal(tc.listName))
+			g.Expect(ns).To(Equal(tc.listNs))
+		})
+	}
+}
+
+func Test_CompleteFlags(t *testing.T) {
+	tt := []struct {
+		name     string
+		args     []string
+		listName string
+		listNs   string
+	}{
+		{
+			name: "the default name and namespace do not narrow the list",
+			args: []string{"--id"},
+		},
+		{
+			name:     "a name and namespace given narrow the list",
+			args:     []string{"--namespace", "ns1", "--name", "a", "--id"},
+			listName: "a",
+			listNs:   "ns1",
+		},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mockClient := new(fakeclient.FakeFlintlockClient)
+			mockClient.ListReturns(&v1alpha1.ListMicroVMsResponse{}, nil)
+
+			cfg := &config.Config{
+				ClientConfig: config.ClientConfig{
+					ClientBuilderFunc: testClient(mockClient, nil),
+				},
+			}
+
+			app := cli.NewApp()
+			app.EnableBashCompletion = true
+			app.Writer = &bytes.Buffer{}
+			app.Commands = []*cli.Command{{
+				Name:         "get",
+				BashComplete: command.CompleteFlags(cfg),
+				Flags: flags.CLIFlags(
+					flags.WithGRPCAddressFlag(),
+					flags.WithNameAndNamespaceFlags(true),
+					flags.WithIDFlag(),
+				),
+			}}
+
+			args := append(append([]string{"hammertime", "get"}, tc.args...), "--generate-bash-completion")
+
+			osArgs := os.Args
+			os.Args = args
+
+			t.Cleanup(func() {
+				os.Args = osArgs
+			})
+
+			g.Expect(app.Run(args)).To(Succeed())
+			g.Expect(mockClient.ListCallCount()).To(Equal(1))
+
+			name, ns := mockClient.ListArgsForCall(0)
+			g.Expect(name).To(Equal(tc.listName))
+			g.Expect(ns).To(Equal(tc.listNs))
+		})
+	}
+}
+
+func Test_CompleteFn_listFails(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.ListReturns(nil, errors.New("boom"))
+
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	g.Expect(command.CompleteFn(utils.NewWriter(buf), cfg, "name")).To(MatchError("boom"))
+	g.Expect(buf.String()).To(BeEmpty())
+}
+
+func Test_CompleteFn_files(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	for _, name := range []string{"spec.json", "values.yaml", "notes.txt", "b.yml"} {
+		g.Expect(os.WriteFile(filepath.Join(dir, name), nil, 0o600)).To(Succeed())
+	}
+
+	g.Expect(os.Mkdir(filepath.Join(dir, "dir.json"), 0o700)).To(Succeed())
+
+	wd, err := os.Getwd()
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(os.Chdir(dir)).To(Succeed())
+
+	t.Cleanup(func() {
+		os.Chdir(wd)
+	})
+
+	buf := &bytes.Buffer{}
+	g.Expect(command.CompleteFn(utils.NewWriter(buf), &config.Config{}, "file")).To(Succeed())
+	g.Expect(buf.String()).To(Equal("b.yml\nspec.json\nvalues.yaml\n"))
+}
+
+func Test_CompletionFn(t *testing.T) {
+	g := NewWithT(t)
+
+	for shell, expected := range map[string]string{
+		"bash": "complete -o bashdefault -o default -F _hammertime_complete hammertime",
+		"zsh":  "compdef _hammertime_complete hammertime",
+		"fish": "complete -c hammertime -f -a '(__hammertime_complete)'",
+	} {
+		buf := &bytes.Buffer{}
+		cfg := &config.Config{Args: []string{shell}}
+
+		g.Expect(command.CompletionFn(utils.NewWriter(buf), cfg)).To(Succeed())
+		g.Expect(buf.String()).To(ContainSubstring(expected))
+		g.Expect(buf.String()).To(ContainSubstring("--generate-bash-completion"))
+	}
+
+	g.Expect(command.CompletionFn(utils.NewWriter(&bytes.Buffer{}), &config.Config{})).To(
+		MatchError("required: bash, zsh or fish"))
+	g.Expect(command.CompletionFn(utils.NewWriter(&bytes.Buffer{}), &config.Config{Args: []string{"tcsh"}})).To(
+		MatchError(`unsupported shell "tcsh", expected bash, zsh or fish`))
+}
@@ -1,16 +1,20 @@
 package command
 
 import (
+	"errors"
 	"os"
 
 	"github.com/urfave/cli/v2"
 	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"k8s.io/utils/pointer"
 
 	"github.com/warehouse-13/hammertime/pkg/client"
 	"github.com/warehouse-13/hammertime/pkg/config"
-	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
 	"github.com/warehouse-13/hammertime/pkg/flags"
 	"github.com/warehouse-13/hammertime/pkg/microvm"
+	"github.com/warehouse-13/hammertime/pkg/preset"
+	"github.com/warehouse-13/hammertime/pkg/resolver"
 	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
@@ -24,17 +28,29 @@ func createCommand() *cli.Command {
 	w := utils.NewWriter(os.Stdout)
 
 	return &cli.Command{
-		Name:    "create",
-		Usage:   "create a new microvm",
-		Aliases: []string{"c"},
-		Before:  flags.ParseFlags(cfg),
+		Name:         "create",
+		Usage:        "create a new microvm",
+		Aliases:      []string{"c"},
+		ArgsUsage:    "[<namespace/name>]",
+		Before:       flags.ParseFlags(cfg),
+		BashComplete: completeFlags(cfg),
 		Flags: flags.CLIFlags(
 			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
 			flags.WithNameAndNamespaceFlags(true),
 			flags.WithJSONSpecFlag(),
+			flags.WithPresetFlag(),
+			flags.WithConfigDirFlag(),
 			flags.WithSSHKeyFlag(),
+			flags.WithUserDataFlags(),
+			flags.WithMetadataFlags(),
+			flags.WithNetworkFlags(),
+			flags.WithDryRunFlag(),
+			flags.WithOutputFlag(outputJSON, outputNDJSON),
 			flags.WithQuietFlag(),
 			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
 		),
 		Action: func(c *cli.Context) error {
 			return CreateFn(w, cfg)
@@ -43,27 +59,53 @@ func createCommand() *cli.Command {
 }
 
 func CreateFn(w utils.Writer, cfg *config.Config) error {
-	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token)
-	if err != nil {
+	var (
+		mvm *types.MicroVMSpec
+		err error
+	)
+
+	if err := checkOutput(cfg, outputJSON, outputNDJSON); err != nil {
 		return err
 	}
 
-	defer client.Close()
-
-	var mvm *types.MicroVMSpec
-
 	if utils.IsSet(cfg.JSONFile) {
-		mvm, err = utils.LoadSpecFromFile(cfg.JSONFile)
+		// The spec names the microvm, so an argument would go unused.
+		if len(cfg.Args) > 0 {
+			return exitcode.New(exitcode.Usage, "create takes a <namespace/name> or a --file, not both")
+		}
+
+		mvm, err = utils.LoadSpecFromFile(cfg.JSONFile, cfg.TemplateValues)
 		if err != nil {
 			return err
 		}
 	} else {
-		mvm, err = newMicroVM(cfg.MvmName, cfg.MvmNamespace, cfg.SSHKeyPath)
+		if err := createTarget(cfg); err != nil {
+			return err
+		}
+
+		mvm, err = newMicroVM(cfg)
 		if err != nil {
 			return err
 		}
 	}
 
+	if cfg.DryRun {
+		// The spec is the user's own input, so nothing is redacted.
+		spec, err := microvm.DecodeSpec(mvm, true)
+		if err != nil {
+			return err
+		}
+
+		return printJSON(w, cfg, spec)
+	}
+
+	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token, cfg.SDKOptions()...)
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
 	res, err := client.Create(mvm)
 	if err != nil {
 		return err
@@ -73,28 +115,135 @@ func CreateFn(w utils.Writer, cfg *config.Config) error {
 		return nil
 	}
 
+	if cfg.Output == outputNDJSON {
+		return w.CompactPrint(res.Microvm)
+	}
+
 	return w.PrettyPrint(res)
 }
 
-func newMicroVM(name, namespace, sshPath string) (*types.MicroVMSpec, error) {
-	mvm := defaults.BaseMicroVM()
+// createTarget takes the name and namespace of the new microvm from the
+// positional argument, if there is one. With --no-defaults both must be set.
+func createTarget(cfg *config.Config) error {
+	if len(cfg.Args) > 1 {
+		return exitcode.New(exitcode.Usage, "create takes a single <namespace/name>")
+	}
+
+	if len(cfg.Args) == 1 {
+		ref, err := resolver.Parse(cfg.Args[0])
+		if err != nil {
+			return err
+		}
+
+		if utils.IsSet(ref.UID) {
+			return exitcode.New(exitcode.Usage, "invalid microvm %q, expected <namespace/name>", cfg.Args[0])
+		}
+
+		cfg.MvmNamespace, cfg.MvmName = ref.Namespace, ref.Name
+	}
+
+	if cfg.NoDefaults && (!utils.IsSet(cfg.MvmName) || !utils.IsSet(cfg.MvmNamespace)) {
+		return exitcode.New(exitcode.Usage, "required: <namespace/name> or --namespace and --name")
+	}
+
+	return nil
+}
+
+func newMicroVM(cfg *config.Config) (*types.MicroVMSpec, error) {
+	mvm, err := presetSpec(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	metaData, err := microvm.CreateMetadata(name, namespace)
+	metaData, err := microvm.CreateMetadata(cfg.MvmName, cfg.MvmNamespace,
+		microvm.WithCloudName(cfg.CloudName),
+		microvm.WithAvailabilityZone(cfg.AvailabilityZone),
+		microvm.WithMetadata(cfg.Metadata),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	userData, err := microvm.CreateUserData(name, sshPath)
+	userData, err := createUserData(cfg)
 	if err != nil {
 		return nil, err
 	}
 
-	mvm.Id = name
-	mvm.Namespace = namespace
+	mvm.Id = cfg.MvmName
+	mvm.Namespace = cfg.MvmNamespace
 	mvm.Metadata = map[string]string{
 		"meta-data": metaData,
 		"user-data": userData,
 	}
 
+	if utils.IsSet(cfg.StaticAddress) {
+		if len(mvm.Interfaces) == 0 {
+			return nil, errors.New("the preset has no interfaces to set --static-address on")
+		}
+
+		mvm.Interfaces[0].Address = &types.StaticAddress{
+			Address:     cfg.StaticAddress,
+			Nameservers: cfg.Nameservers,
+		}
+
+		if utils.IsSet(cfg.Gateway) {
+			mvm.Interfaces[0].Address.Gateway = pointer.String(cfg.Gateway)
+		}
+	}
+
+	if cfg.NetworkConfig {
+		networkConfig, err := microvm.CreateNetworkConfig(mvm.Interfaces)
+		if err != nil {
+			return nil, err
+		}
+
+		// flintlock would otherwise replace our network-config with its own.
+		if mvm.Kernel != nil {
+			mvm.Kernel.AddNetworkConfig = false
+		}
+
+		mvm.Metadata["network-config"] = networkConfig
+	}
+
 	return mvm, nil
 }
+
+// presetSpec returns the spec of the configured preset to build a new Microvm
+// from.
+func presetSpec(cfg *config.Config) (*types.MicroVMSpec, error) {
+	dir, err := preset.ConfigDir(cfg.ConfigDir)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := preset.Get(dir, cfg.Preset)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.Spec, nil
+}
+
+func createUserData(cfg *config.Config) (string, error) {
+	// A user-data file used as is has no place for the keys, so rather than
+	// drop them the user is asked to merge.
+	if utils.IsSet(cfg.UserDataFile) && !cfg.UserDataMerge {
+		if len(cfg.SSHKeyPaths) > 0 || cfg.SSHAgent {
+			return "", exitcode.New(exitcode.Usage,
+				"--public-key-path and --ssh-agent need --user-data-merge to be added to a --user-data-file")
+		}
+
+		return microvm.CreateUserDataFromFile(cfg.MvmName, nil, cfg.UserDataFile, false)
+	}
+
+	sshKeys, err := microvm.LoadSSHKeys(cfg.SSHKeyPaths, cfg.SSHAgent)
+	if err != nil {
+		return "", err
+	}
+
+	if utils.IsSet(cfg.UserDataFile) {
+		return microvm.CreateUserDataFromFile(cfg.MvmName, sshKeys, cfg.UserDataFile, true)
+	}
+
+	return microvm.CreateUserData(cfg.MvmName, sshKeys)
+}
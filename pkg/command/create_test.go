@@ -2,8 +2,10 @@ package command_test
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
+	"io/ioutil"
 	"os"
 	"testing"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
 	"github.com/warehouse-13/hammertime/pkg/command"
 	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
 	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
@@ -82,6 +85,105 @@ func Test_CreateFn_silent(t *testing.T) {
 	g.Expect(buf.String()).To(BeEmpty())
 }
 
+func Test_CreateFn_ndjson(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmName:      "foo",
+		MvmNamespace: "bar",
+		Output:       "ndjson",
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := createResponse("foo", "bar")
+	mockClient.CreateReturns(resp, nil)
+	g.Expect(command.CreateFn(w, cfg)).To(Succeed())
+
+	g.Expect(bytes.Count(buf.Bytes(), []byte("\n"))).To(Equal(1))
+
+	out := &types.MicroVM{}
+	g.Expect(json.Unmarshal(buf.Bytes(), out)).To(Succeed())
+	g.Expect(out).To(Equal(resp.Microvm))
+}
+
+func Test_CreateFn_arg(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmName:      "mvm0",
+		MvmNamespace: "ns0",
+		Args:         []string{"bar/foo"},
+		Silent:       true,
+	}
+
+	mockClient.CreateReturns(createResponse("foo", "bar"), nil)
+	g.Expect(command.CreateFn(utils.NewWriter(nil), cfg)).To(Succeed())
+
+	input := mockClient.CreateArgsForCall(0)
+	g.Expect(input.Id).To(Equal("foo"))
+	g.Expect(input.Namespace).To(Equal("bar"))
+}
+
+func Test_CreateFn_arg_uid(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"abc123"},
+	}
+
+	g.Expect(command.CreateFn(utils.NewWriter(nil), cfg)).To(MatchError(ContainSubstring("expected <namespace/name>")))
+	g.Expect(mockClient.CreateCallCount()).To(BeZero())
+}
+
+func Test_CreateFn_arg_withFile(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:     []string{"bar/foo"},
+		JSONFile: "spec.yaml",
+	}
+
+	err := command.CreateFn(utils.NewWriter(nil), cfg)
+	g.Expect(err).To(MatchError("create takes a <namespace/name> or a --file, not both"))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Usage))
+	g.Expect(mockClient.CreateCallCount()).To(BeZero())
+}
+
+func Test_CreateFn_noDefaults(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmNamespace: "bar",
+		NoDefaults:   true,
+	}
+
+	err := command.CreateFn(utils.NewWriter(nil), cfg)
+	g.Expect(err).To(MatchError("required: <namespace/name> or --namespace and --name"))
+	g.Expect(mockClient.CreateCallCount()).To(BeZero())
+}
+
 func Test_CreateFn_clientFails(t *testing.T) {
 	g := NewWithT(t)
 
@@ -167,3 +269,201 @@ func Test_CreateFn_withFile_fails(t *testing.T) {
 
 	g.Expect(command.CreateFn(utils.NewWriter(nil), cfg)).NotTo(Succeed())
 }
+
+func Test_CreateFn_withUserDataFile(t *testing.T) {
+	g := NewWithT(t)
+
+	userData := "#cloud-config\npackages:\n  - curl\n"
+
+	tempFile, err := ioutil.TempFile("", "createfn_test")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ioutil.WriteFile(tempFile.Name(), []byte(userData), 0755)).To(Succeed())
+
+	t.Cleanup(func() {
+		g.Expect(os.RemoveAll(tempFile.Name())).To(Succeed())
+	})
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmName:      "foo",
+		MvmNamespace: "bar",
+		UserDataFile: tempFile.Name(),
+	}
+
+	mockClient.CreateReturns(createResponse("foo", "bar"), nil)
+	g.Expect(command.CreateFn(utils.NewWriter(&bytes.Buffer{}), cfg)).To(Succeed())
+
+	input := mockClient.CreateArgsForCall(0)
+	g.Expect(input.Metadata).To(HaveKeyWithValue("user-data", base64.StdEncoding.EncodeToString([]byte(userData))))
+}
+
+func Test_CreateFn_withUserDataFile_keys(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmName:      "foo",
+		MvmNamespace: "bar",
+		UserDataFile: "user-data.yaml",
+		SSHAgent:     true,
+	}
+
+	err := command.CreateFn(utils.NewWriter(nil), cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("need --user-data-merge")))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Usage))
+	g.Expect(mockClient.CreateCallCount()).To(BeZero())
+}
+
+func Test_CreateFn_withUserDataFile_merge_loadsKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	tempFile, err := ioutil.TempFile("", "createfn_test")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(ioutil.WriteFile(tempFile.Name(), []byte("#cloud-config\n"), 0755)).To(Succeed())
+
+	t.Cleanup(func() {
+		g.Expect(os.RemoveAll(tempFile.Name())).To(Succeed())
+	})
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmName:       "foo",
+		MvmNamespace:  "bar",
+		UserDataFile:  tempFile.Name(),
+		UserDataMerge: true,
+		SSHKeyPaths:   []string{"noexist"},
+	}
+
+	g.Expect(command.CreateFn(utils.NewWriter(nil), cfg)).NotTo(Succeed())
+	g.Expect(mockClient.CreateCallCount()).To(BeZero())
+}
+
+func Test_CreateFn_withUserDataFile_fails(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		UserDataFile: "noexist",
+	}
+
+	g.Expect(command.CreateFn(utils.NewWriter(nil), cfg)).NotTo(Succeed())
+	g.Expect(mockClient.CreateCallCount()).To(BeZero())
+}
+
+func Test_CreateFn_withStaticAddress(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmName:       "foo",
+		MvmNamespace:  "bar",
+		StaticAddress: "192.168.100.10/24",
+		Gateway:       "192.168.100.1",
+		Nameservers:   []string{"8.8.8.8"},
+		NetworkConfig: true,
+		CloudName:     "lab",
+	}
+
+	mockClient.CreateReturns(createResponse("foo", "bar"), nil)
+	g.Expect(command.CreateFn(utils.NewWriter(&bytes.Buffer{}), cfg)).To(Succeed())
+
+	input := mockClient.CreateArgsForCall(0)
+	g.Expect(input.Interfaces[0].Address.Address).To(Equal(cfg.StaticAddress))
+	g.Expect(*input.Interfaces[0].Address.Gateway).To(Equal(cfg.Gateway))
+	g.Expect(input.Interfaces[0].Address.Nameservers).To(Equal(cfg.Nameservers))
+	g.Expect(input.Kernel.AddNetworkConfig).To(BeFalse())
+	g.Expect(input.Metadata).To(HaveKey("network-config"))
+
+	metaData, err := base64.StdEncoding.DecodeString(input.Metadata["meta-data"])
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(metaData)).To(ContainSubstring("cloud_name: lab"))
+}
+
+func Test_CreateFn_withPreset(t *testing.T) {
+	g := NewWithT(t)
+
+	configDir := t.TempDir()
+	g.Expect(os.MkdirAll(configDir+"/presets", 0o755)).To(Succeed())
+	g.Expect(ioutil.WriteFile(configDir+"/presets/tiny.yaml", []byte(tinyPreset), 0o600)).To(Succeed())
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmName:      "foo",
+		MvmNamespace: "bar",
+		Preset:       "tiny",
+		ConfigDir:    configDir,
+	}
+
+	mockClient.CreateReturns(createResponse("foo", "bar"), nil)
+	g.Expect(command.CreateFn(utils.NewWriter(&bytes.Buffer{}), cfg)).To(Succeed())
+
+	input := mockClient.CreateArgsForCall(0)
+	g.Expect(input.Id).To(Equal("foo"))
+	g.Expect(input.Vcpu).To(Equal(int32(1)))
+	g.Expect(input.MemoryInMb).To(Equal(int32(512)))
+	g.Expect(input.Metadata).To(HaveKey("user-data"))
+
+	cfg.StaticAddress = "192.168.100.10/24"
+	g.Expect(command.CreateFn(utils.NewWriter(&bytes.Buffer{}), cfg)).To(MatchError(ContainSubstring("has no interfaces")))
+}
+
+func Test_CreateFn_presetNotFound(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Preset:    "huge",
+		ConfigDir: t.TempDir(),
+	}
+
+	g.Expect(command.CreateFn(utils.NewWriter(nil), cfg)).To(MatchError(ContainSubstring(`preset "huge" not found`)))
+	g.Expect(mockClient.CreateCallCount()).To(BeZero())
+}
+
+func Test_CreateFn_dryRun(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmName:      "foo",
+		MvmNamespace: "bar",
+		DryRun:       true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	g.Expect(command.CreateFn(w, cfg)).To(Succeed())
+	g.Expect(mockClient.CreateCallCount()).To(BeZero())
+
+	out := map[string]interface{}{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &out)).To(Succeed())
+	g.Expect(out).To(HaveKeyWithValue("id", "foo"))
+	g.Expect(out).To(HaveKeyWithValue("namespace", "bar"))
+	g.Expect(out["metadata"]).To(HaveKeyWithValue("meta-data", HaveKeyWithValue("local_hostname", "foo")))
+	g.Expect(out["metadata"]).To(HaveKeyWithValue("user-data", HaveKeyWithValue("hostname", "foo")))
+}
@@ -3,12 +3,17 @@ package command
 import (
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 
 	"github.com/urfave/cli/v2"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
 
 	"github.com/warehouse-13/hammertime/pkg/client"
 	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
 	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/resolver"
 	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
@@ -17,23 +22,32 @@ func deleteCommand() *cli.Command {
 		ClientConfig: config.ClientConfig{
 			ClientBuilderFunc: client.New,
 		},
+		Stdin:       os.Stdin,
+		Interactive: utils.IsTerminal(os.Stdin),
 	}
 
 	w := utils.NewWriter(os.Stdout)
 
 	return &cli.Command{
-		Name:    "delete",
-		Usage:   "delete a microvmd",
-		Aliases: []string{"d"},
-		Before:  flags.ParseFlags(cfg),
+		Name:         "delete",
+		Usage:        "delete a microvmd",
+		Aliases:      []string{"d"},
+		Before:       flags.ParseFlags(cfg),
+		BashComplete: completeFlags(cfg),
 		Flags: flags.CLIFlags(
 			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
 			flags.WithNameAndNamespaceFlags(false),
 			flags.WithIDFlag(),
 			flags.WithJSONSpecFlag(),
 			flags.WithAllFlag(),
+			flags.WithParallelismFlag(),
+			flags.WithDeleteSafetyFlags(),
+			flags.WithDryRunFlag(),
 			flags.WithQuietFlag(),
 			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
 		),
 		Action: func(c *cli.Context) error {
 			return DeleteFn(w, cfg)
@@ -42,43 +56,64 @@ func deleteCommand() *cli.Command {
 }
 
 func DeleteFn(w utils.Writer, cfg *config.Config) error {
-	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token)
+	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token, cfg.SDKOptions()...)
 	if err != nil {
 		return err
 	}
 
 	defer client.Close()
 
-	if utils.IsSet(cfg.JSONFile) {
-		var err error
+	refs, err := targetRefs(cfg)
+	if err != nil {
+		return err
+	}
+
+	resolve := resolver.New(client)
+
+	if len(refs) > 1 {
+		return deleteTargets(w, client, cfg, resolve, refs)
+	}
+
+	ref := refs[0]
 
-		cfg.UUID, cfg.MvmName, cfg.MvmNamespace, err = utils.ProcessFile(cfg.JSONFile)
+	// If it is possible to delete by set UUID, do that and exit
+	if utils.IsSet(ref.UID) {
+		mvm, err := resolve.One(ref)
 		if err != nil {
 			return err
 		}
-	}
 
-	// If it is possible to delete by set UUID, do that and exit
-	if utils.IsSet(cfg.UUID) {
-		return deleteMvm(w, client, cfg.UUID, cfg.Silent)
+		if checkProtection(cfg) {
+			if err := refuseProtected(cfg, []*types.MicroVM{mvm}); err != nil {
+				return err
+			}
+		}
+
+		if cfg.DryRun {
+			w.Print(mvm.Spec.GetUid())
+
+			return nil
+		}
+
+		return deleteMvm(w, client, mvm.Spec.GetUid(), cfg.Silent)
 	}
 
 	// If UUID is not present, make sure that required spec is set
 	if missingSpec(cfg) {
-		return fmt.Errorf("required: --namespace, --name")
+		return exitcode.New(exitcode.Usage, "required: --namespace, --name")
 	}
 
 	// Get all microvms
-	list, err := client.List(cfg.MvmName, cfg.MvmNamespace)
+	mvms, err := resolve.Find(ref)
 	if err != nil {
 		return err
 	}
 
 	// Do not auto-delete multple mvms, inform and exit
-	if len(list.Microvm) > 1 && doNotDeleteAll(cfg) {
-		w.Printf("%d MicroVMs found under %s/%s:\n", len(list.Microvm), cfg.MvmNamespace, cfg.MvmName)
+	if len(mvms) > 1 && doNotDeleteAll(cfg) {
+		w.Printf("%d MicroVMs found under %s/%s:\n", len(mvms), cfg.MvmNamespace, cfg.MvmName)
 
-		for _, mvm := range list.Microvm {
+		for _, mvm := range mvms {
 			w.Print(*mvm.Spec.Uid)
 		}
 
@@ -87,14 +122,109 @@ func DeleteFn(w utils.Writer, cfg *config.Config) error {
 		return nil
 	}
 
+	if checkProtection(cfg) {
+		if err := refuseProtected(cfg, mvms); err != nil {
+			return err
+		}
+	}
+
+	// Only say what would be deleted
+	if cfg.DryRun {
+		for _, mvm := range mvms {
+			w.Print(*mvm.Spec.Uid)
+		}
+
+		return nil
+	}
+
+	// Give the user a chance to back out of a mass deletion
+	if cfg.DeleteAll && cfg.Interactive && !cfg.Yes && len(mvms) > 0 {
+		ok, err := confirmDelete(w, cfg, mvms)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			w.Print("Aborted, nothing was deleted.")
+
+			return nil
+		}
+	}
+
 	// By this point we assume the user wants everything dead
-	for _, mvm := range list.Microvm {
-		if err := deleteMvm(w, client, *mvm.Spec.Uid, cfg.Silent); err != nil {
+	if len(mvms) == 1 {
+		return deleteMvm(w, client, *mvms[0].Spec.Uid, cfg.Silent)
+	}
+
+	return deleteMvms(w, client, cfg, mvms)
+}
+
+// deleteTargets deletes the microvms given as several positional arguments.
+// Every target is resolved before anything is deleted. Each must match exactly
+// one microvm unless --all is set.
+func deleteTargets(
+	w utils.Writer, c client.FlintlockClient, cfg *config.Config, resolve *resolver.Resolver, refs []resolver.Ref,
+) error {
+	var (
+		mvms = []*types.MicroVM{}
+		seen = map[string]bool{}
+	)
+
+	for _, ref := range refs {
+		found, err := resolve.Find(ref)
+		if err != nil {
+			return err
+		}
+
+		if len(found) == 0 {
+			return exitcode.New(exitcode.NotFound, "MicroVM %s not found", ref)
+		}
+
+		if len(found) > 1 && !cfg.DeleteAll {
+			return exitcode.New(exitcode.Usage,
+				"%d MicroVMs found under %s, use a uid or re-run command with `--all`", len(found), ref)
+		}
+
+		for _, mvm := range found {
+			if !seen[mvm.Spec.GetUid()] {
+				seen[mvm.Spec.GetUid()] = true
+				mvms = append(mvms, mvm)
+			}
+		}
+	}
+
+	if checkProtection(cfg) {
+		if err := refuseProtected(cfg, mvms); err != nil {
+			return err
+		}
+	}
+
+	if cfg.DryRun {
+		for _, mvm := range mvms {
+			w.Print(mvm.Spec.GetUid())
+		}
+
+		return nil
+	}
+
+	if cfg.DeleteAll && cfg.Interactive && !cfg.Yes {
+		ok, err := confirmDelete(w, cfg, mvms)
+		if err != nil {
 			return err
 		}
+
+		if !ok {
+			w.Print("Aborted, nothing was deleted.")
+
+			return nil
+		}
+	}
+
+	if len(mvms) == 1 {
+		return deleteMvm(w, c, mvms[0].Spec.GetUid(), cfg.Silent)
 	}
 
-	return nil
+	return deleteMvms(w, c, cfg, mvms)
 }
 
 func deleteMvm(w utils.Writer, c client.FlintlockClient, u string, s bool) error { //nolint: varnamelen // acceptable
@@ -110,6 +240,86 @@ func deleteMvm(w utils.Writer, c client.FlintlockClient, u string, s bool) error
 	return w.PrettyPrint(res)
 }
 
+// deleteMvms deletes the microvms concurrently, at most cfg.Parallelism at a
+// time. Failures do not stop the remaining deletions; a summary is printed at
+// the end and an error returned if any failed. The error carries the failures
+// as details, and only lists them in its message when the summary was not
+// printed. Its exit code is the one shared by all the failures, if they agree.
+func deleteMvms(w utils.Writer, c client.FlintlockClient, cfg *config.Config, mvms []*types.MicroVM) error {
+	parallelism := cfg.Parallelism
+	if parallelism < 1 {
+		parallelism = 1
+	}
+
+	var (
+		errs = make([]error, len(mvms))
+		sem  = make(chan struct{}, parallelism)
+		wg   sync.WaitGroup
+	)
+
+	for i, mvm := range mvms {
+		wg.Add(1)
+
+		sem <- struct{}{}
+
+		go func(i int, uid string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			_, errs[i] = c.Delete(uid)
+		}(i, mvm.Spec.GetUid())
+	}
+
+	wg.Wait()
+
+	deleted, failed := []string{}, []string{}
+	code := exitcode.OK
+
+	for i, mvm := range mvms {
+		if errs[i] != nil {
+			failed = append(failed, fmt.Sprintf("%s: %s", describeMvm(mvm), errs[i]))
+
+			switch errCode := exitcode.FromError(errs[i]).Code; code {
+			case exitcode.OK, errCode:
+				code = errCode
+			default:
+				code = exitcode.Failure
+			}
+
+			continue
+		}
+
+		deleted = append(deleted, describeMvm(mvm))
+	}
+
+	if !cfg.Silent {
+		w.Printf("Deleted %d of %d MicroVMs:\n", len(deleted), len(mvms))
+
+		for _, line := range deleted {
+			w.Print(line)
+		}
+
+		if len(failed) > 0 {
+			w.Printf("Failed to delete %d MicroVMs:\n", len(failed))
+
+			for _, line := range failed {
+				w.Print(line)
+			}
+		}
+	}
+
+	if len(failed) == 0 {
+		return nil
+	}
+
+	message := fmt.Sprintf("failed to delete %d of %d MicroVMs", len(failed), len(mvms))
+	if cfg.Silent {
+		message += ":\n" + strings.Join(failed, "\n")
+	}
+
+	return &exitcode.Error{Code: code, Message: message, Details: failed}
+}
+
 func missingSpec(cfg *config.Config) bool {
 	return !cfg.DeleteAll && (!utils.IsSet(cfg.MvmName) || !utils.IsSet(cfg.MvmNamespace))
 }
@@ -117,3 +327,42 @@ func missingSpec(cfg *config.Config) bool {
 func doNotDeleteAll(cfg *config.Config) bool {
 	return utils.IsSet(cfg.MvmName) && utils.IsSet(cfg.MvmNamespace) && !cfg.DeleteAll
 }
+
+func checkProtection(cfg *config.Config) bool {
+	return utils.IsSet(cfg.ProtectionLabel) && !cfg.Force
+}
+
+// refuseProtected returns an error listing the microvms which carry the
+// protection label, if there are any.
+func refuseProtected(cfg *config.Config, mvms []*types.MicroVM) error {
+	protected := []string{}
+
+	for _, mvm := range mvms {
+		if mvm != nil && mvm.Spec != nil && utils.HasLabel(mvm.Spec.Labels, cfg.ProtectionLabel) {
+			protected = append(protected, describeMvm(mvm))
+		}
+	}
+
+	if len(protected) == 0 {
+		return nil
+	}
+
+	return exitcode.New(exitcode.Protected,
+		"refusing to delete %d protected MicroVMs (labelled %s), re-run with --force to delete them:\n%s",
+		len(protected), cfg.ProtectionLabel, strings.Join(protected, "\n"),
+	)
+}
+
+func confirmDelete(w utils.Writer, cfg *config.Config, mvms []*types.MicroVM) (bool, error) {
+	w.Printf("The following %d MicroVMs will be deleted:\n", len(mvms))
+
+	for _, mvm := range mvms {
+		w.Print(describeMvm(mvm))
+	}
+
+	return utils.Confirm(w, cfg.Stdin, "Continue?")
+}
+
+func describeMvm(mvm *types.MicroVM) string {
+	return "  " + resolver.Describe(mvm)
+}
@@ -5,15 +5,20 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
 	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
 	"k8s.io/utils/pointer"
 
 	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
 	"github.com/warehouse-13/hammertime/pkg/command"
 	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
 	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
@@ -33,6 +38,7 @@ func Test_DeleteFn(t *testing.T) {
 	buf := &bytes.Buffer{}
 	w := utils.NewWriter(buf)
 
+	mockClient.GetReturns(getResponse("foo", "bar", testUid), nil)
 	mockClient.DeleteReturns(deleteResponse(), nil)
 	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
 
@@ -59,6 +65,7 @@ func Test_DeleteFn_silent(t *testing.T) {
 	buf := &bytes.Buffer{}
 	w := utils.NewWriter(buf)
 
+	mockClient.GetReturns(getResponse("foo", "bar", testUid), nil)
 	mockClient.DeleteReturns(deleteResponse(), nil)
 	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
 
@@ -129,6 +136,7 @@ func Test_DeleteFn_withFile(t *testing.T) {
 	buf := &bytes.Buffer{}
 	w := utils.NewWriter(buf)
 
+	mockClient.GetReturns(getResponse("foo", "bar", testUid), nil)
 	mockClient.DeleteReturns(deleteResponse(), nil)
 	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
 
@@ -243,13 +251,14 @@ func Test_DeleteFn_noUid_deleteAll(t *testing.T) {
 	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
 
 	g.Expect(mockClient.DeleteCallCount()).To(Equal(mvmCount))
-
-	input := mockClient.DeleteArgsForCall(0)
-	g.Expect(input).To(Equal(*resp.Microvm[0].Spec.Uid))
-	input = mockClient.DeleteArgsForCall(1)
-	g.Expect(input).To(Equal(*resp.Microvm[1].Spec.Uid))
-
-	g.Expect(buf.String()).To(Equal("{}\n{}\n"))
+	g.Expect([]string{mockClient.DeleteArgsForCall(0), mockClient.DeleteArgsForCall(1)}).To(ConsistOf(
+		*resp.Microvm[0].Spec.Uid, *resp.Microvm[1].Spec.Uid,
+	))
+
+	g.Expect(buf.String()).To(Equal(fmt.Sprintf(
+		"Deleted 2 of 2 MicroVMs:\n  %[1]s/%[1]s %[2]s\n  %[1]s/%[1]s %[3]s\n",
+		testName, *resp.Microvm[0].Spec.Uid, *resp.Microvm[1].Spec.Uid,
+	)))
 }
 
 func Test_DeleteFn_noUid_deleteAll_silent(t *testing.T) {
@@ -282,11 +291,9 @@ func Test_DeleteFn_noUid_deleteAll_silent(t *testing.T) {
 	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
 
 	g.Expect(mockClient.DeleteCallCount()).To(Equal(mvmCount))
-
-	input := mockClient.DeleteArgsForCall(0)
-	g.Expect(input).To(Equal(*resp.Microvm[0].Spec.Uid))
-	input = mockClient.DeleteArgsForCall(1)
-	g.Expect(input).To(Equal(*resp.Microvm[1].Spec.Uid))
+	g.Expect([]string{mockClient.DeleteArgsForCall(0), mockClient.DeleteArgsForCall(1)}).To(ConsistOf(
+		*resp.Microvm[0].Spec.Uid, *resp.Microvm[1].Spec.Uid,
+	))
 
 	g.Expect(buf.String()).To(BeEmpty())
 }
@@ -317,3 +324,344 @@ func Test_DeleteFn_clientBuilderFails(t *testing.T) {
 
 	g.Expect(command.DeleteFn(utils.NewWriter(nil), cfg)).NotTo(Succeed())
 }
+
+func Test_DeleteFn_dryRun_deleteAll(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		DeleteAll: true,
+		DryRun:    true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := listResponse(2, "foo", "bar")
+	mockClient.ListReturns(resp, nil)
+
+	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
+
+	name, ns := mockClient.ListArgsForCall(0)
+	g.Expect(name).To(BeEmpty())
+	g.Expect(ns).To(BeEmpty())
+	g.Expect(mockClient.DeleteCallCount()).To(BeZero())
+	g.Expect(buf.String()).To(Equal(*resp.Microvm[0].Spec.Uid + "\n" + *resp.Microvm[1].Spec.Uid + "\n"))
+}
+
+func Test_DeleteFn_dryRun_byUid(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		UUID:   "123abc",
+		DryRun: true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	mockClient.GetReturns(getResponse("foo", "bar", "123abc"), nil)
+	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
+	g.Expect(mockClient.DeleteCallCount()).To(BeZero())
+	g.Expect(buf.String()).To(Equal("123abc\n"))
+}
+
+func Test_DeleteFn_arg_byUidPrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:   []string{"123a"},
+		Silent: true,
+	}
+
+	resp := listResponse(1, "foo", "bar")
+	resp.Microvm[0].Spec.Uid = pointer.String("123abc")
+
+	mockClient.GetReturns(nil, status.Error(codes.NotFound, "not found"))
+	mockClient.ListReturns(resp, nil)
+	mockClient.DeleteReturns(deleteResponse(), nil)
+	g.Expect(command.DeleteFn(utils.NewWriter(nil), cfg)).To(Succeed())
+
+	g.Expect(mockClient.DeleteArgsForCall(0)).To(Equal("123abc"))
+}
+
+func Test_DeleteFn_arg_byName(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"bar/foo"},
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	mockClient.ListReturns(listResponse(2, "foo", "bar"), nil)
+	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
+
+	inName, inNamespace := mockClient.ListArgsForCall(0)
+	g.Expect(inName).To(Equal("foo"))
+	g.Expect(inNamespace).To(Equal("bar"))
+	g.Expect(mockClient.DeleteCallCount()).To(BeZero())
+	g.Expect(buf.String()).To(ContainSubstring("2 MicroVMs found under bar/foo"))
+}
+
+func Test_DeleteFn_args_multiple(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:        []string{"abc123", "def456", "abc123"},
+		Parallelism: 1,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	mockClient.GetReturnsOnCall(0, getResponse("foo", "bar", "abc123"), nil)
+	mockClient.GetReturnsOnCall(1, getResponse("baz", "bar", "def456"), nil)
+	mockClient.GetReturnsOnCall(2, getResponse("foo", "bar", "abc123"), nil)
+	mockClient.DeleteReturns(deleteResponse(), nil)
+	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
+
+	g.Expect(mockClient.DeleteCallCount()).To(Equal(2))
+	g.Expect(mockClient.DeleteArgsForCall(0)).To(Equal("abc123"))
+	g.Expect(mockClient.DeleteArgsForCall(1)).To(Equal("def456"))
+	g.Expect(buf.String()).To(ContainSubstring("Deleted 2 of 2 MicroVMs"))
+}
+
+func Test_DeleteFn_args_oneNotFound(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"abc123", "bar/baz"},
+	}
+
+	mockClient.GetReturns(getResponse("foo", "bar", "abc123"), nil)
+	mockClient.ListReturns(listResponse(0, "", ""), nil)
+
+	err := command.DeleteFn(utils.NewWriter(nil), cfg)
+	g.Expect(err).To(MatchError("MicroVM bar/baz not found"))
+	g.Expect(mockClient.DeleteCallCount()).To(BeZero())
+}
+
+func Test_DeleteFn_args_multipleMatches(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"abc123", "bar/foo"},
+	}
+
+	mockClient.GetReturns(getResponse("baz", "bar", "abc123"), nil)
+	mockClient.ListReturns(listResponse(2, "foo", "bar"), nil)
+
+	err := command.DeleteFn(utils.NewWriter(nil), cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("2 MicroVMs found under bar/foo")))
+	g.Expect(mockClient.DeleteCallCount()).To(BeZero())
+}
+
+func Test_DeleteFn_deleteAll_confirmed(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		DeleteAll:   true,
+		Silent:      true,
+		Interactive: true,
+		Stdin:       strings.NewReader("yes\n"),
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := listResponse(2, "foo", "bar")
+	mockClient.ListReturns(resp, nil)
+	mockClient.DeleteReturns(deleteResponse(), nil)
+
+	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
+	g.Expect(mockClient.DeleteCallCount()).To(Equal(2))
+	g.Expect(buf.String()).To(Equal(fmt.Sprintf(
+		"The following 2 MicroVMs will be deleted:\n  bar/foo %s\n  bar/foo %s\nContinue? [y/N]: ",
+		*resp.Microvm[0].Spec.Uid, *resp.Microvm[1].Spec.Uid,
+	)))
+}
+
+func Test_DeleteFn_deleteAll_notConfirmed(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		DeleteAll:   true,
+		Interactive: true,
+		Stdin:       strings.NewReader("\n"),
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	mockClient.ListReturns(listResponse(2, "foo", "bar"), nil)
+
+	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
+	g.Expect(mockClient.DeleteCallCount()).To(BeZero())
+	g.Expect(buf.String()).To(HaveSuffix("Aborted, nothing was deleted.\n"))
+}
+
+func Test_DeleteFn_deleteAll_yes(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		DeleteAll:   true,
+		Silent:      true,
+		Interactive: true,
+		Yes:         true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	mockClient.ListReturns(listResponse(2, "foo", "bar"), nil)
+	mockClient.DeleteReturns(deleteResponse(), nil)
+
+	g.Expect(command.DeleteFn(w, cfg)).To(Succeed())
+	g.Expect(mockClient.DeleteCallCount()).To(Equal(2))
+	g.Expect(buf.String()).To(BeEmpty())
+}
+
+func Test_DeleteFn_protected(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		DeleteAll:       true,
+		Silent:          true,
+		ProtectionLabel: "hammertime.io/protected=true",
+	}
+
+	resp := listResponse(2, "foo", "bar")
+	resp.Microvm[1].Spec.Labels = map[string]string{"hammertime.io/protected": "true"}
+	mockClient.ListReturns(resp, nil)
+	mockClient.DeleteReturns(deleteResponse(), nil)
+
+	err := command.DeleteFn(utils.NewWriter(nil), cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("refusing to delete 1 protected MicroVMs")))
+	g.Expect(err).To(MatchError(ContainSubstring(*resp.Microvm[1].Spec.Uid)))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Protected))
+	g.Expect(mockClient.DeleteCallCount()).To(BeZero())
+
+	cfg.Force = true
+	g.Expect(command.DeleteFn(utils.NewWriter(nil), cfg)).To(Succeed())
+	g.Expect(mockClient.DeleteCallCount()).To(Equal(2))
+}
+
+func Test_DeleteFn_protected_byUid(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		UUID:            "123abc",
+		ProtectionLabel: "hammertime.io/protected",
+	}
+
+	resp := getResponse("foo", "bar", "123abc")
+	resp.Microvm.Spec.Labels = map[string]string{"hammertime.io/protected": "yes"}
+	mockClient.GetReturns(resp, nil)
+
+	g.Expect(command.DeleteFn(utils.NewWriter(nil), cfg)).To(MatchError(ContainSubstring("--force")))
+	g.Expect(mockClient.GetArgsForCall(0)).To(Equal("123abc"))
+	g.Expect(mockClient.DeleteCallCount()).To(BeZero())
+
+	resp.Microvm.Spec.Labels = map[string]string{"hammertime.io/protected": "false", "other": "true"}
+	cfg.ProtectionLabel = "hammertime.io/protected=true"
+	mockClient.DeleteReturns(deleteResponse(), nil)
+
+	g.Expect(command.DeleteFn(utils.NewWriter(&bytes.Buffer{}), cfg)).To(Succeed())
+	g.Expect(mockClient.DeleteCallCount()).To(Equal(1))
+}
+
+func Test_DeleteFn_deleteAll_partialFailure(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		DeleteAll:   true,
+		Parallelism: 2,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := listResponse(4, "foo", "bar")
+	failUid := *resp.Microvm[1].Spec.Uid
+	mockClient.ListReturns(resp, nil)
+	mockClient.DeleteStub = func(uid string) (*emptypb.Empty, error) {
+		if uid == failUid {
+			return nil, errors.New("boom")
+		}
+
+		return deleteResponse(), nil
+	}
+
+	err := command.DeleteFn(w, cfg)
+	g.Expect(err).To(MatchError("failed to delete 1 of 4 MicroVMs"))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Failure))
+	g.Expect(exitcode.FromError(err).Details).To(Equal([]string{"  bar/foo " + failUid + ": boom"}))
+	g.Expect(mockClient.DeleteCallCount()).To(Equal(4))
+
+	g.Expect(buf.String()).To(ContainSubstring("Deleted 3 of 4 MicroVMs:\n"))
+	g.Expect(buf.String()).To(ContainSubstring("Failed to delete 1 MicroVMs:\n  bar/foo " + failUid + ": boom\n"))
+
+	buf.Reset()
+	mockClient.DeleteStub = func(uid string) (*emptypb.Empty, error) {
+		return nil, status.Error(codes.NotFound, "gone")
+	}
+	cfg.Silent = true
+
+	err = command.DeleteFn(w, cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("failed to delete 4 of 4 MicroVMs:\n  bar/foo ")))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.NotFound))
+	g.Expect(buf.String()).To(BeEmpty())
+}
@@ -0,0 +1,4 @@
+package command
+
+// CompleteFlags exposes completeFlags to the tests.
+var CompleteFlags = completeFlags
@@ -0,0 +1,93 @@
+package command
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/exporter"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+const metricsPath = "/metrics"
+
+func exporterCommand() *cli.Command {
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: client.New,
+		},
+	}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:      "exporter",
+		Usage:     "serve prometheus metrics about the microvms on flintlock servers",
+		ArgsUsage: "[address...]",
+		Before:    flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
+			flags.WithListenFlag(defaults.ExporterListen),
+			flags.WithRefreshFlag(defaults.ExporterRefresh),
+			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
+		),
+		Action: func(c *cli.Context) error {
+			return ExporterFn(w, cfg)
+		},
+	}
+}
+
+// ExporterFn lists the microvms on each server given as an argument, or the
+// --grpc-address if there are none, every cfg.Refresh and serves the results
+// as prometheus metrics on cfg.Listen until interrupted.
+func ExporterFn(w utils.Writer, cfg *config.Config) error {
+	addresses := cfg.Args
+	if len(addresses) == 0 {
+		addresses = []string{cfg.GRPCAddress}
+	}
+
+	refresh := cfg.Refresh
+	if refresh <= 0 {
+		refresh = defaults.ExporterRefresh
+	}
+
+	// A scrape must not outlast the refresh, or a hung host would stall them
+	// all.
+	opts := append(cfg.SDKOptions(), sdk.WithCallTimeout(refresh))
+
+	hosts := []exporter.Host{}
+
+	for _, address := range addresses {
+		client, err := cfg.ClientBuilderFunc(address, cfg.Token, opts...)
+		if err != nil {
+			return err
+		}
+
+		defer client.Close()
+
+		hosts = append(hosts, exporter.Host{Address: address, Client: client})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	exp := exporter.New(hosts, refresh)
+	go exp.Run(ctx, refresh)
+
+	mux := http.NewServeMux()
+	mux.Handle(metricsPath, exp.Handler())
+
+	return listenAndServe(ctx, w, cfg.Listen, mux, "metrics", metricsPath)
+}
@@ -1,7 +1,7 @@
 package command
 
 import (
-	"fmt"
+	"encoding/json"
 	"os"
 
 	"github.com/urfave/cli/v2"
@@ -9,7 +9,10 @@ import (
 
 	"github.com/warehouse-13/hammertime/pkg/client"
 	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
 	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/microvm"
+	"github.com/warehouse-13/hammertime/pkg/resolver"
 	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
@@ -23,17 +26,23 @@ func getCommand() *cli.Command {
 	w := utils.NewWriter(os.Stdout)
 
 	return &cli.Command{
-		Name:    "get",
-		Usage:   "get an existing microvm",
-		Aliases: []string{"g"},
-		Before:  flags.ParseFlags(cfg),
+		Name:         "get",
+		Usage:        "get an existing microvm",
+		Aliases:      []string{"g"},
+		Before:       flags.ParseFlags(cfg),
+		BashComplete: completeFlags(cfg),
 		Flags: flags.CLIFlags(
 			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
 			flags.WithNameAndNamespaceFlags(true),
 			flags.WithJSONSpecFlag(),
 			flags.WithStateFlag(),
 			flags.WithIDFlag(),
+			flags.WithShowMetadataFlags(),
+			flags.WithOutputFlag(outputJSON, outputNDJSON, outputMetadata),
 			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
 		),
 		Action: func(c *cli.Context) error {
 			return GetFn(w, cfg)
@@ -42,16 +51,37 @@ func getCommand() *cli.Command {
 }
 
 func GetFn(w utils.Writer, cfg *config.Config) error {
-	if utils.IsSet(cfg.JSONFile) {
-		var err error
+	refs, err := targetRefs(cfg)
+	if err != nil {
+		return err
+	}
 
-		cfg.UUID, cfg.MvmName, cfg.MvmNamespace, err = utils.ProcessFile(cfg.JSONFile)
-		if err != nil {
+	for _, ref := range refs {
+		if !utils.IsSet(ref.UID) && (!utils.IsSet(ref.Name) || !utils.IsSet(ref.Namespace)) {
+			return exitcode.New(exitcode.Usage, "required: <namespace/name>, <uid>, --id or --namespace and --name")
+		}
+	}
+
+	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token, cfg.SDKOptions()...)
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	resolve := resolver.New(client)
+
+	for _, ref := range refs {
+		if err := getMicrovm(w, cfg, resolve, ref); err != nil {
 			return err
 		}
 	}
 
-	res, err := findMicrovm(cfg)
+	return nil
+}
+
+func getMicrovm(w utils.Writer, cfg *config.Config, resolve *resolver.Resolver, ref resolver.Ref) error {
+	res, err := resolve.Find(ref)
 	if err != nil {
 		return err
 	}
@@ -63,43 +93,150 @@ func GetFn(w utils.Writer, cfg *config.Config) error {
 			return nil
 		}
 
-		return w.PrettyPrint(res[0])
+		return printMicrovm(w, cfg, res[0])
 	}
 
 	if len(res) > 1 {
-		w.Printf("%d MicroVMs found under %s/%s:\n", len(res), cfg.MvmNamespace, cfg.MvmName)
+		return printMicrovms(w, cfg, ref, res)
+	}
+
+	return exitcode.New(exitcode.NotFound, "MicroVM %s not found", ref)
+}
 
-		for _, mvm := range res {
-			w.Print(*mvm.Spec.Uid)
+// printMicrovms prints all of the Microvms a ref matched: one line each for
+// ndjson, which keeps its output the same shape however many are found, or
+// else a JSON array. The state and metadata describe a single Microvm, so for
+// those the ref must be told apart.
+func printMicrovms(w utils.Writer, cfg *config.Config, ref resolver.Ref, mvms []*types.MicroVM) error {
+	if err := checkOutput(cfg, outputJSON, outputNDJSON, outputMetadata); err != nil {
+		return err
+	}
+
+	if cfg.State || cfg.Output == outputMetadata {
+		return resolver.Ambiguous(ref, mvms)
+	}
+
+	if cfg.Output == outputNDJSON {
+		for _, mvm := range mvms {
+			if err := printMicrovm(w, cfg, mvm); err != nil {
+				return err
+			}
 		}
 
 		return nil
 	}
 
-	return fmt.Errorf("MicroVM %s/%s not found", cfg.MvmNamespace, cfg.MvmName)
+	docs := make([]interface{}, 0, len(mvms))
+
+	for _, mvm := range mvms {
+		doc, err := microvmDocument(cfg, mvm)
+		if err != nil {
+			return err
+		}
+
+		docs = append(docs, doc)
+	}
+
+	return printJSON(w, cfg, docs)
 }
 
-func findMicrovm(cfg *config.Config) ([]*types.MicroVM, error) {
-	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token)
+func printMicrovm(w utils.Writer, cfg *config.Config, mvm *types.MicroVM) error {
+	switch cfg.Output {
+	case outputMetadata:
+		text, err := microvm.FormatMetadata(mvm.Spec.Metadata, cfg.Reveal)
+		if err != nil {
+			return err
+		}
+
+		w.Printf("%s", text)
+
+		return nil
+	case "", outputJSON, outputNDJSON:
+	default:
+		return exitcode.New(exitcode.Usage, "unsupported output format: %s", cfg.Output)
+	}
+
+	doc, err := microvmDocument(cfg, mvm)
+	if err != nil {
+		return err
+	}
+
+	return printJSON(w, cfg, doc)
+}
+
+// microvmDocument returns what to print for a Microvm as JSON: the Microvm
+// itself, or with --show-metadata a copy with its metadata decoded.
+func microvmDocument(cfg *config.Config, mvm *types.MicroVM) (interface{}, error) {
+	if !cfg.ShowMetadata {
+		return mvm, nil
+	}
+
+	// Round trip through JSON so that the metadata can be swapped for its
+	// decoded form without losing the protobuf field names.
+	out, err := toMap(mvm)
 	if err != nil {
 		return nil, err
 	}
 
-	defer client.Close()
+	spec, err := microvm.DecodeSpec(mvm.Spec, cfg.Reveal)
+	if err != nil {
+		return nil, err
+	}
 
-	if utils.IsSet(cfg.UUID) {
-		res, err := client.Get(cfg.UUID)
+	out["spec"] = spec
+
+	return out, nil
+}
+
+func toMap(obj interface{}) (map[string]interface{}, error) {
+	dat, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(dat, &out); err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
+
+// targetRefs returns the microvms a command acts on: the positional arguments
+// (each `namespace/name` or a uid), or else the --file, --id or --name and
+// --namespace flags. With a single target the config is updated to match.
+func targetRefs(cfg *config.Config) ([]resolver.Ref, error) {
+	if len(cfg.Args) > 0 {
+		refs := make([]resolver.Ref, 0, len(cfg.Args))
+
+		for _, arg := range cfg.Args {
+			ref, err := resolver.Parse(arg)
+			if err != nil {
+				return nil, err
+			}
+
+			refs = append(refs, ref)
+		}
+
+		if len(refs) == 1 {
+			cfg.UUID, cfg.MvmNamespace, cfg.MvmName = refs[0].UID, refs[0].Namespace, refs[0].Name
+		}
+
+		return refs, nil
+	}
+
+	if utils.IsSet(cfg.JSONFile) {
+		var err error
+
+		cfg.UUID, cfg.MvmName, cfg.MvmNamespace, err = utils.ProcessFile(cfg.JSONFile, cfg.TemplateValues)
 		if err != nil {
 			return nil, err
 		}
-
-		return []*types.MicroVM{res.Microvm}, nil
 	}
 
-	res, err := client.List(cfg.MvmName, cfg.MvmNamespace)
-	if err != nil {
-		return nil, err
+	if utils.IsSet(cfg.UUID) {
+		return []resolver.Ref{{UID: cfg.UUID}}, nil
 	}
 
-	return res.Microvm, nil
+	return []resolver.Ref{{Namespace: cfg.MvmNamespace, Name: cfg.MvmName}}, nil
 }
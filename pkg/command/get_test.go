@@ -2,19 +2,24 @@ package command_test
 
 import (
 	"bytes"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
 	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"k8s.io/utils/pointer"
 
 	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
 	"github.com/warehouse-13/hammertime/pkg/command"
 	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
 	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
@@ -168,7 +173,8 @@ func Test_GetFn_uidNotSet_multipleMatches(t *testing.T) {
 	buf := &bytes.Buffer{}
 	w := utils.NewWriter(buf)
 
-	mockClient.ListReturns(listResponse(2, testName, testNamespace), nil)
+	resp := listResponse(2, testName, testNamespace)
+	mockClient.ListReturns(resp, nil)
 	g.Expect(command.GetFn(w, cfg)).To(Succeed())
 
 	g.Expect(mockClient.GetCallCount()).To(BeZero())
@@ -176,7 +182,171 @@ func Test_GetFn_uidNotSet_multipleMatches(t *testing.T) {
 	g.Expect(inName).To(Equal(testName))
 	g.Expect(inNamespace).To(Equal(testNamespace))
 
-	g.Expect(buf.String()).To(ContainSubstring(fmt.Sprintf("2 MicroVMs found under %s/%s", testNamespace, testName)))
+	out := []*types.MicroVM{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &out)).To(Succeed())
+	g.Expect(out).To(Equal(resp.Microvm))
+}
+
+func Test_GetFn_multipleMatches_state(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:  []string{"bar/foo"},
+		State: true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	mockClient.ListReturns(listResponse(2, "foo", "bar"), nil)
+
+	err := command.GetFn(w, cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("2 MicroVMs found under bar/foo, use a uid instead")))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Usage))
+	g.Expect(buf.String()).To(BeEmpty())
+}
+
+func Test_GetFn_arg_byName(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"bar/foo"},
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	mockClient.ListReturns(listResponse(1, "foo", "bar"), nil)
+	g.Expect(command.GetFn(w, cfg)).To(Succeed())
+
+	g.Expect(mockClient.GetCallCount()).To(BeZero())
+	inName, inNamespace := mockClient.ListArgsForCall(0)
+	g.Expect(inName).To(Equal("foo"))
+	g.Expect(inNamespace).To(Equal("bar"))
+}
+
+func Test_GetFn_arg_byUidPrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:  []string{"abc1"},
+		State: true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := listResponse(2, "foo", "bar")
+	resp.Microvm[0].Spec.Uid = pointer.String("abc123")
+	resp.Microvm[0].Status.State = types.MicroVMStatus_FAILED
+	resp.Microvm[1].Spec.Uid = pointer.String("def456")
+
+	mockClient.GetReturns(nil, status.Error(codes.NotFound, "not found"))
+	mockClient.ListReturns(resp, nil)
+	g.Expect(command.GetFn(w, cfg)).To(Succeed())
+
+	g.Expect(mockClient.GetArgsForCall(0)).To(Equal("abc1"))
+	g.Expect(buf.String()).To(Equal("FAILED\n"))
+}
+
+func Test_GetFn_args_multiple(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:  []string{"abc123", "def456"},
+		State: true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	second := getResponse("baz", "bar", "def456")
+	second.Microvm.Status.State = types.MicroVMStatus_FAILED
+
+	mockClient.GetReturnsOnCall(0, getResponse("foo", "bar", "abc123"), nil)
+	mockClient.GetReturnsOnCall(1, second, nil)
+	g.Expect(command.GetFn(w, cfg)).To(Succeed())
+
+	g.Expect(mockClient.GetArgsForCall(0)).To(Equal("abc123"))
+	g.Expect(mockClient.GetArgsForCall(1)).To(Equal("def456"))
+	g.Expect(buf.String()).To(Equal("CREATED\nFAILED\n"))
+}
+
+func Test_GetFn_noTarget(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		MvmNamespace: "bar",
+	}
+
+	err := command.GetFn(utils.NewWriter(nil), cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("required: <namespace/name>, <uid>")))
+	g.Expect(mockClient.ListCallCount()).To(BeZero())
+}
+
+func Test_GetFn_arg_invalid(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"bar/"},
+	}
+
+	err := command.GetFn(utils.NewWriter(nil), cfg)
+	g.Expect(err).To(MatchError(ContainSubstring("expected <namespace/name> or <uid>")))
+	g.Expect(mockClient.ListCallCount()).To(BeZero())
+}
+
+func Test_GetFn_ndjson_multipleMatches(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:   []string{"bar/foo"},
+		Output: "ndjson",
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := listResponse(2, "foo", "bar")
+	mockClient.ListReturns(resp, nil)
+	g.Expect(command.GetFn(w, cfg)).To(Succeed())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	g.Expect(lines).To(HaveLen(2))
+
+	for i, line := range lines {
+		out := &types.MicroVM{}
+		g.Expect(json.Unmarshal([]byte(line), out)).To(Succeed())
+		g.Expect(out).To(Equal(resp.Microvm[i]))
+	}
 }
 
 func Test_GetFn_withFile(t *testing.T) {
@@ -290,3 +460,84 @@ func Test_GetFn_clientBuilderFails(t *testing.T) {
 
 	g.Expect(command.GetFn(utils.NewWriter(nil), cfg)).NotTo(Succeed())
 }
+
+func Test_GetFn_showMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	var testUid = "abc123"
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		UUID:         testUid,
+		ShowMetadata: true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := getResponse("foo", "bar", testUid)
+	resp.Microvm.Spec.Metadata = map[string]string{
+		"user-data": base64.StdEncoding.EncodeToString([]byte("#cloud-config\nhostname: foo\npassword: hunter2\n")),
+	}
+	mockClient.GetReturns(resp, nil)
+	g.Expect(command.GetFn(w, cfg)).To(Succeed())
+
+	out := struct {
+		Spec struct {
+			Uid      string                            `json:"uid"`
+			Metadata map[string]map[string]interface{} `json:"metadata"`
+		} `json:"spec"`
+	}{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &out)).To(Succeed())
+
+	g.Expect(out.Spec.Uid).To(Equal(testUid))
+	g.Expect(out.Spec.Metadata["user-data"]).To(HaveKeyWithValue("hostname", "foo"))
+	g.Expect(out.Spec.Metadata["user-data"]).To(HaveKeyWithValue("password", "REDACTED"))
+}
+
+func Test_GetFn_outputMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	var testUid = "abc123"
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		UUID:   testUid,
+		Output: "metadata",
+		Reveal: true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := getResponse("foo", "bar", testUid)
+	resp.Microvm.Spec.Metadata = map[string]string{
+		"user-data": base64.StdEncoding.EncodeToString([]byte("#cloud-config\npassword: hunter2\n")),
+	}
+	mockClient.GetReturns(resp, nil)
+	g.Expect(command.GetFn(w, cfg)).To(Succeed())
+
+	g.Expect(buf.String()).To(Equal("--- user-data\n#cloud-config\npassword: hunter2\n"))
+}
+
+func Test_GetFn_unsupportedOutput(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		UUID:   "abc123",
+		Output: "xml",
+	}
+
+	mockClient.GetReturns(getResponse("foo", "bar", "abc123"), nil)
+	g.Expect(command.GetFn(utils.NewWriter(nil), cfg)).To(MatchError("unsupported output format: xml"))
+}
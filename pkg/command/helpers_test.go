@@ -9,14 +9,17 @@ import (
 	"time"
 
 	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/internal/fixtures"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
 	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
 	"github.com/weaveworks-liquidmetal/flintlock/api/types"
 	"google.golang.org/protobuf/types/known/emptypb"
-	"k8s.io/utils/pointer"
 )
 
-func testClient(c client.FlintlockClient, err error) func(string, string) (client.FlintlockClient, error) {
-	return func(string, string) (client.FlintlockClient, error) {
+type clientBuilderFunc func(string, string, ...sdk.Option) (client.FlintlockClient, error)
+
+func testClient(c client.FlintlockClient, err error) clientBuilderFunc {
+	return func(string, string, ...sdk.Option) (client.FlintlockClient, error) {
 		return c, err
 	}
 }
@@ -40,14 +43,10 @@ func writeFile(spec *types.MicroVMSpec) (*os.File, error) {
 }
 
 func createResponse(name, namespace string) *v1alpha1.CreateMicroVMResponse {
-	return &v1alpha1.CreateMicroVMResponse{
-		Microvm: &types.MicroVM{
-			Spec: &types.MicroVMSpec{
-				Id:        name,
-				Namespace: namespace,
-			},
-		},
-	}
+	mvm := fixtures.MicroVM(namespace, name, "")
+	mvm.Spec.Uid = nil
+
+	return fixtures.CreateResponse(mvm)
 }
 
 func deleteResponse() *emptypb.Empty {
@@ -55,41 +54,18 @@ func deleteResponse() *emptypb.Empty {
 }
 
 func getResponse(name, namespace, uid string) *v1alpha1.GetMicroVMResponse {
-	return &v1alpha1.GetMicroVMResponse{
-		Microvm: &types.MicroVM{
-			Spec: &types.MicroVMSpec{
-				Id:        name,
-				Namespace: namespace,
-				Uid:       pointer.String(uid),
-			},
-			Status: &types.MicroVMStatus{
-				State: types.MicroVMStatus_CREATED,
-			},
-		},
-	}
+	return fixtures.GetResponse(fixtures.MicroVM(namespace, name, uid, fixtures.WithState(types.MicroVMStatus_CREATED)))
 }
 
 func listResponse(count int, name, namespace string) *v1alpha1.ListMicroVMsResponse {
 	mvms := []*types.MicroVM{}
 
 	for i := 0; i < count; i++ {
-		mvm := &types.MicroVM{
-			Spec: &types.MicroVMSpec{
-				Id:        name,
-				Namespace: namespace,
-				Uid:       pointer.String(randomString(10)),
-			},
-			Status: &types.MicroVMStatus{
-				State: types.MicroVMStatus_CREATED,
-			},
-		}
-
+		mvm := fixtures.MicroVM(namespace, name, randomString(10), fixtures.WithState(types.MicroVMStatus_CREATED))
 		mvms = append(mvms, mvm)
 	}
 
-	return &v1alpha1.ListMicroVMsResponse{
-		Microvm: mvms,
-	}
+	return fixtures.ListResponse(mvms...)
 }
 
 func randomString(length int) string {
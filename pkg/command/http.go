@@ -0,0 +1,47 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+const (
+	readHeaderTimeout = 10 * time.Second
+	shutdownTimeout   = 5 * time.Second
+)
+
+// listenAndServe serves handler on address until ctx is done, then shuts the
+// server down gracefully. What is served, and where, is printed once the
+// address is bound.
+func listenAndServe(
+	ctx context.Context, w utils.Writer, address string, handler http.Handler, what, path string,
+) error {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Handler: handler, ReadHeaderTimeout: readHeaderTimeout}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+
+		server.Shutdown(shutdownCtx) //nolint: errcheck // we are exiting anyway
+	}()
+
+	w.Printf("Serving %s on http://%s%s\n", what, listener.Addr(), path)
+
+	if err := server.Serve(listener); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+
+	return nil
+}
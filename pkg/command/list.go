@@ -21,14 +21,19 @@ func listCommand() *cli.Command {
 	w := utils.NewWriter(os.Stdout)
 
 	return &cli.Command{
-		Name:    "list",
-		Usage:   "list microvms",
-		Aliases: []string{"l"},
-		Before:  flags.ParseFlags(cfg),
+		Name:         "list",
+		Usage:        "list microvms",
+		Aliases:      []string{"l"},
+		Before:       flags.ParseFlags(cfg),
+		BashComplete: completeFlags(cfg),
 		Flags: flags.CLIFlags(
 			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
 			flags.WithNameAndNamespaceFlags(false),
+			flags.WithOutputFlag(outputJSON, outputNDJSON),
 			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
 		),
 		Action: func(c *cli.Context) error {
 			return ListFn(w, cfg)
@@ -37,7 +42,11 @@ func listCommand() *cli.Command {
 }
 
 func ListFn(w utils.Writer, cfg *config.Config) error {
-	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token)
+	if err := checkOutput(cfg, outputJSON, outputNDJSON); err != nil {
+		return err
+	}
+
+	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token, cfg.SDKOptions()...)
 	if err != nil {
 		return err
 	}
@@ -49,5 +58,15 @@ func ListFn(w utils.Writer, cfg *config.Config) error {
 		return err
 	}
 
-	return w.PrettyPrint(res)
+	if cfg.Output != outputNDJSON {
+		return w.PrettyPrint(res)
+	}
+
+	for _, mvm := range res.Microvm {
+		if err := w.CompactPrint(mvm); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
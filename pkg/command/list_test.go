@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"strings"
 	"testing"
 
 	. "github.com/onsi/gomega"
 	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
 
 	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
 	"github.com/warehouse-13/hammertime/pkg/command"
@@ -50,6 +52,49 @@ func Test_ListFn(t *testing.T) {
 	g.Expect(out.Microvm).To(HaveLen(2))
 }
 
+func Test_ListFn_ndjson(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Output: "ndjson",
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := listResponse(2, "foo", "bar")
+	mockClient.ListReturns(resp, nil)
+	g.Expect(command.ListFn(w, cfg)).To(Succeed())
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	g.Expect(lines).To(HaveLen(2))
+
+	for i, line := range lines {
+		out := &types.MicroVM{}
+		g.Expect(json.Unmarshal([]byte(line), out)).To(Succeed())
+		g.Expect(out).To(Equal(resp.Microvm[i]))
+	}
+}
+
+func Test_ListFn_unsupportedOutput(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Output: "yaml",
+	}
+
+	g.Expect(command.ListFn(utils.NewWriter(nil), cfg)).To(MatchError("unsupported output format: yaml"))
+	g.Expect(mockClient.ListCallCount()).To(BeZero())
+}
+
 func Test_ListFn_clientFails(t *testing.T) {
 	g := NewWithT(t)
 
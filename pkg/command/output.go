@@ -0,0 +1,39 @@
+package command
+
+import (
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+const (
+	outputJSON       = "json"
+	outputNDJSON     = "ndjson"
+	outputMetadata   = "metadata"
+	outputTable      = "table"
+	outputPrometheus = "prometheus"
+)
+
+// printJSON writes obj as pretty JSON, or on a single line for ndjson.
+func printJSON(w utils.Writer, cfg *config.Config, obj interface{}) error {
+	if cfg.Output == outputNDJSON {
+		return w.CompactPrint(obj)
+	}
+
+	return w.PrettyPrint(obj)
+}
+
+// checkOutput returns an error if the output format is not one of formats.
+func checkOutput(cfg *config.Config, formats ...string) error {
+	if cfg.Output == "" {
+		return nil
+	}
+
+	for _, format := range formats {
+		if cfg.Output == format {
+			return nil
+		}
+	}
+
+	return exitcode.New(exitcode.Usage, "unsupported output format: %s", cfg.Output)
+}
@@ -0,0 +1,200 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+const (
+	checkHealth = "health"
+	checkList   = "list"
+
+	authOK     = "ok"
+	authFailed = "failed"
+	authNone   = "none"
+)
+
+// PingResult is the outcome of checking a single flintlock server.
+type PingResult struct {
+	// Address is the server which was checked.
+	Address string `json:"address"`
+	// Reachable is true if a connection could be made.
+	Reachable bool `json:"reachable"`
+	// Healthy is true if the server answered the check.
+	Healthy bool `json:"healthy"`
+	// Check is how the server was checked: `health` if it runs the gRPC health
+	// service, otherwise `list`.
+	Check string `json:"check,omitempty"`
+	// Auth is `ok` or `failed` if a token was given, `none` otherwise.
+	Auth string `json:"auth,omitempty"`
+	// Latency is the time taken to connect and check the server.
+	Latency string `json:"latency"`
+	// Error is why the server is unreachable or unhealthy.
+	Error string `json:"error,omitempty"`
+}
+
+func pingCommand() *cli.Command {
+	cfg := &config.Config{}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:      "ping",
+		Usage:     "check that flintlock servers are reachable and healthy",
+		Aliases:   []string{"status"},
+		ArgsUsage: "[address...]",
+		Before:    flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithTimeoutFlag(),
+			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
+		),
+		Action: func(c *cli.Context) error {
+			return PingFn(w, cfg)
+		},
+	}
+}
+
+// PingFn checks each server given as an argument, or the --grpc-address if
+// there are none, and prints the results. An error is returned if any check
+// failed.
+func PingFn(w utils.Writer, cfg *config.Config) error {
+	addresses := cfg.Args
+	if len(addresses) == 0 {
+		addresses = []string{cfg.GRPCAddress}
+	}
+
+	results := make([]PingResult, len(addresses))
+
+	var wg sync.WaitGroup
+
+	for i, address := range addresses {
+		wg.Add(1)
+
+		go func(i int, address string) {
+			defer wg.Done()
+
+			results[i] = ping(cfg, address)
+		}(i, address)
+	}
+
+	wg.Wait()
+
+	if err := w.PrettyPrint(results); err != nil {
+		return err
+	}
+
+	return pingError(results)
+}
+
+func ping(cfg *config.Config, address string) (result PingResult) {
+	result = PingResult{Address: address, Auth: authNone}
+
+	if err := dialler.ValidateAddress(address); err != nil {
+		result.Error = err.Error()
+
+		return result
+	}
+
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaults.PingTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	defer func() {
+		result.Latency = time.Since(start).Round(time.Microsecond).String()
+	}()
+
+	conn, err := dialler.NewWithContext(ctx, address, cfg.Token, append(sdk.GRPCOptions(cfg.SDKOptions()...), grpc.WithBlock()))
+	if err != nil {
+		result.Error = err.Error()
+
+		return result
+	}
+
+	defer conn.Close()
+
+	result.Reachable = true
+	result.Check = checkHealth
+
+	health, err := grpc_health_v1.NewHealthClient(conn).Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err == nil && health.Status != grpc_health_v1.HealthCheckResponse_SERVING {
+		err = fmt.Errorf("health status: %s", health.Status)
+	}
+
+	if status.Code(err) == codes.Unimplemented {
+		result.Check = checkList
+		_, err = v1alpha1.NewMicroVMClient(conn).ListMicroVMs(ctx, &v1alpha1.ListMicroVMsRequest{})
+	}
+
+	if utils.IsSet(cfg.Token) {
+		result.Auth = authOK
+	}
+
+	if status.Code(err) == codes.Unauthenticated {
+		result.Auth = authFailed
+	}
+
+	if err != nil {
+		result.Error = exitcode.FromError(err).Error()
+
+		return result
+	}
+
+	result.Healthy = true
+
+	return result
+}
+
+// pingError returns an error with the most relevant exit code if any server
+// failed its check.
+func pingError(results []PingResult) error {
+	var unreachable, unauthenticated, unhealthy int
+
+	for _, result := range results {
+		switch {
+		case !result.Reachable:
+			unreachable++
+		case result.Auth == authFailed:
+			unauthenticated++
+		case !result.Healthy:
+			unhealthy++
+		}
+	}
+
+	switch {
+	case unreachable > 0:
+		return exitcode.New(exitcode.Unavailable, "%d of %d servers unreachable", unreachable, len(results))
+	case unauthenticated > 0:
+		return exitcode.New(exitcode.Unauthenticated, "%d of %d servers rejected the token", unauthenticated, len(results))
+	case unhealthy > 0:
+		return exitcode.New(exitcode.ServerError, "%d of %d servers unhealthy", unhealthy, len(results))
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,156 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/warehouse-13/safety"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/warehouse-13/hammertime/pkg/command"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_PingFn_listFallback(t *testing.T) {
+	g := NewWithT(t)
+
+	fakeserver := safety.New()
+	address := fakeserver.Start("secret")
+
+	t.Cleanup(func() {
+		fakeserver.Stop()
+	})
+
+	buf := &bytes.Buffer{}
+	cfg := &config.Config{
+		GRPCAddress: address,
+		Token:       "secret",
+		ClientConfig: config.ClientConfig{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	g.Expect(command.PingFn(utils.NewWriter(buf), cfg)).To(Succeed())
+
+	results := []command.PingResult{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &results)).To(Succeed())
+	g.Expect(results).To(HaveLen(1))
+	g.Expect(results[0].Address).To(Equal(address))
+	g.Expect(results[0].Reachable).To(BeTrue())
+	g.Expect(results[0].Healthy).To(BeTrue())
+	g.Expect(results[0].Check).To(Equal("list"))
+	g.Expect(results[0].Auth).To(Equal("ok"))
+	g.Expect(results[0].Latency).NotTo(BeEmpty())
+}
+
+func Test_PingFn_badToken(t *testing.T) {
+	g := NewWithT(t)
+
+	fakeserver := safety.New()
+	address := fakeserver.Start("secret")
+
+	t.Cleanup(func() {
+		fakeserver.Stop()
+	})
+
+	buf := &bytes.Buffer{}
+	cfg := &config.Config{
+		Token: "wrong",
+		Args:  []string{address},
+		ClientConfig: config.ClientConfig{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	err := command.PingFn(utils.NewWriter(buf), cfg)
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Unauthenticated))
+
+	results := []command.PingResult{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &results)).To(Succeed())
+	g.Expect(results[0].Reachable).To(BeTrue())
+	g.Expect(results[0].Healthy).To(BeFalse())
+	g.Expect(results[0].Auth).To(Equal("failed"))
+}
+
+func Test_PingFn_healthService(t *testing.T) {
+	g := NewWithT(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+
+	go server.Serve(listener) //nolint: errcheck // test server
+
+	t.Cleanup(server.Stop)
+
+	buf := &bytes.Buffer{}
+	cfg := &config.Config{
+		Args: []string{listener.Addr().String()},
+		ClientConfig: config.ClientConfig{
+			Timeout: 5 * time.Second,
+		},
+	}
+
+	g.Expect(command.PingFn(utils.NewWriter(buf), cfg)).To(Succeed())
+
+	results := []command.PingResult{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &results)).To(Succeed())
+	g.Expect(results[0].Check).To(Equal("health"))
+	g.Expect(results[0].Healthy).To(BeTrue())
+	g.Expect(results[0].Auth).To(Equal("none"))
+
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+	buf.Reset()
+	err = command.PingFn(utils.NewWriter(buf), cfg)
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.ServerError))
+	g.Expect(buf.String()).To(ContainSubstring("NOT_SERVING"))
+}
+
+func Test_PingFn_severalHosts_unreachable(t *testing.T) {
+	g := NewWithT(t)
+
+	fakeserver := safety.New()
+	address := fakeserver.Start("")
+
+	t.Cleanup(func() {
+		fakeserver.Stop()
+	})
+
+	// Grab a free port and release it, so nothing is listening there.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+	deadAddress := listener.Addr().String()
+	g.Expect(listener.Close()).To(Succeed())
+
+	buf := &bytes.Buffer{}
+	cfg := &config.Config{
+		Args: []string{address, deadAddress},
+		ClientConfig: config.ClientConfig{
+			Timeout: 500 * time.Millisecond,
+		},
+	}
+
+	err = command.PingFn(utils.NewWriter(buf), cfg)
+	g.Expect(err).To(MatchError("1 of 2 servers unreachable"))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Unavailable))
+
+	results := []command.PingResult{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &results)).To(Succeed())
+	g.Expect(results).To(HaveLen(2))
+	g.Expect(results[0].Healthy).To(BeTrue())
+	g.Expect(results[1].Address).To(Equal(deadAddress))
+	g.Expect(results[1].Reachable).To(BeFalse())
+	g.Expect(results[1].Error).NotTo(BeEmpty())
+}
@@ -0,0 +1,103 @@
+package command
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/preset"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func presetCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "preset",
+		Usage: "list and show the presets microvms can be created from",
+		Subcommands: []*cli.Command{
+			presetListCommand(),
+			presetShowCommand(),
+		},
+	}
+}
+
+func presetListCommand() *cli.Command {
+	cfg := &config.Config{}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:   "list",
+		Usage:  "list the built-in presets and those in the config directory",
+		Before: flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithConfigDirFlag(),
+		),
+		Action: func(c *cli.Context) error {
+			return PresetListFn(w, cfg)
+		},
+	}
+}
+
+func presetShowCommand() *cli.Command {
+	cfg := &config.Config{}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:      "show",
+		Usage:     "show the spec of a preset",
+		ArgsUsage: "<name>",
+		Before:    flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithConfigDirFlag(),
+		),
+		Action: func(c *cli.Context) error {
+			return PresetShowFn(w, cfg)
+		},
+	}
+}
+
+func PresetListFn(w utils.Writer, cfg *config.Config) error {
+	dir, err := preset.ConfigDir(cfg.ConfigDir)
+	if err != nil {
+		return err
+	}
+
+	presets, err := preset.List(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range presets {
+		if utils.IsSet(p.Error) {
+			w.Printf("%s\t%s\t(invalid: %s)\n", p.Name, p.Source, p.Error)
+
+			continue
+		}
+
+		w.Printf("%s\t%s\n", p.Name, p.Source)
+	}
+
+	return nil
+}
+
+func PresetShowFn(w utils.Writer, cfg *config.Config) error {
+	if len(cfg.Args) == 0 {
+		return exitcode.New(exitcode.Usage, "required: <name>")
+	}
+
+	dir, err := preset.ConfigDir(cfg.ConfigDir)
+	if err != nil {
+		return err
+	}
+
+	p, err := preset.Get(dir, cfg.Args[0])
+	if err != nil {
+		return err
+	}
+
+	return w.PrettyPrint(p.Spec)
+}
@@ -0,0 +1,75 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+
+	"github.com/warehouse-13/hammertime/pkg/command"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+// tinyPreset is the smallest preset which is valid.
+const tinyPreset = `
+vcpu: 1
+memory_in_mb: 512
+kernel:
+  image: kernel
+root_volume:
+  id: root
+  source:
+    container_source: os
+`
+
+func Test_PresetListFn(t *testing.T) {
+	g := NewWithT(t)
+
+	configDir := t.TempDir()
+	presetFile := filepath.Join(configDir, "presets", "tiny.yaml")
+	g.Expect(os.MkdirAll(filepath.Dir(presetFile), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(presetFile, []byte(tinyPreset), 0o600)).To(Succeed())
+
+	brokenFile := filepath.Join(configDir, "presets", "broken.yaml")
+	g.Expect(os.WriteFile(brokenFile, []byte("vcpu: 1\n"), 0o600)).To(Succeed())
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	g.Expect(command.PresetListFn(w, &config.Config{ConfigDir: configDir})).To(Succeed())
+	g.Expect(buf.String()).To(Equal(
+		"broken\t" + brokenFile + "\t(invalid: invalid preset " + brokenFile + ": memory_in_mb must be set)\n" +
+			"default\tbuilt-in\n" +
+			"tiny\t" + presetFile + "\n",
+	))
+}
+
+func Test_PresetShowFn(t *testing.T) {
+	g := NewWithT(t)
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	cfg := &config.Config{
+		ConfigDir: t.TempDir(),
+		Args:      []string{"default"},
+	}
+
+	g.Expect(command.PresetShowFn(w, cfg)).To(Succeed())
+
+	out := &types.MicroVMSpec{}
+	g.Expect(json.Unmarshal(buf.Bytes(), out)).To(Succeed())
+	g.Expect(out.Kernel.Image).To(Equal(defaults.KernelImage))
+}
+
+func Test_PresetShowFn_noName(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(command.PresetShowFn(utils.NewWriter(nil), &config.Config{})).To(MatchError("required: <name>"))
+}
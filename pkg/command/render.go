@@ -0,0 +1,43 @@
+package command
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func renderCommand() *cli.Command {
+	cfg := &config.Config{}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:   "render",
+		Usage:  "print the spec rendered from a --file template without sending it",
+		Before: flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithJSONSpecFlag(),
+		),
+		Action: func(c *cli.Context) error {
+			return RenderFn(w, cfg)
+		},
+	}
+}
+
+func RenderFn(w utils.Writer, cfg *config.Config) error {
+	if !utils.IsSet(cfg.JSONFile) {
+		return exitcode.New(exitcode.Usage, "required: --file")
+	}
+
+	mvm, err := utils.LoadSpecFromFile(cfg.JSONFile, cfg.TemplateValues)
+	if err != nil {
+		return err
+	}
+
+	return w.PrettyPrint(mvm)
+}
@@ -0,0 +1,72 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+
+	"github.com/warehouse-13/hammertime/pkg/command"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_RenderFn(t *testing.T) {
+	g := NewWithT(t)
+
+	tempFile, err := ioutil.TempFile("", "renderfn_test")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Cleanup(func() {
+		g.Expect(os.RemoveAll(tempFile.Name())).To(Succeed())
+	})
+
+	g.Expect(ioutil.WriteFile(tempFile.Name(), []byte(`{"id": "{{ .name }}", "namespace": "{{ .ns }}"}`), 0755)).To(Succeed())
+
+	cfg := &config.Config{
+		JSONFile: tempFile.Name(),
+		TemplateValues: map[string]interface{}{
+			"name": "foo",
+			"ns":   "bar",
+		},
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	g.Expect(command.RenderFn(w, cfg)).To(Succeed())
+
+	out := &types.MicroVMSpec{}
+	g.Expect(json.Unmarshal(buf.Bytes(), out)).To(Succeed())
+	g.Expect(out.Id).To(Equal("foo"))
+	g.Expect(out.Namespace).To(Equal("bar"))
+}
+
+func Test_RenderFn_missingVariable(t *testing.T) {
+	g := NewWithT(t)
+
+	tempFile, err := ioutil.TempFile("", "renderfn_test")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Cleanup(func() {
+		g.Expect(os.RemoveAll(tempFile.Name())).To(Succeed())
+	})
+
+	g.Expect(ioutil.WriteFile(tempFile.Name(), []byte(`{"id": "${name}"}`), 0755)).To(Succeed())
+
+	cfg := &config.Config{
+		JSONFile: tempFile.Name(),
+	}
+
+	g.Expect(command.RenderFn(utils.NewWriter(nil), cfg)).To(MatchError(ContainSubstring("undefined variables: name")))
+}
+
+func Test_RenderFn_noFile(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(command.RenderFn(utils.NewWriter(nil), &config.Config{})).To(MatchError("required: --file"))
+}
@@ -0,0 +1,107 @@
+package command
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/gateway"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func serveCommand() *cli.Command {
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: client.New,
+		},
+	}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:      "serve",
+		Usage:     "serve a REST api in front of flintlock servers",
+		ArgsUsage: "[address...]",
+		Before:    flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
+			flags.WithListenFlag(defaults.ServeListen),
+			flags.WithPresetFlag(),
+			flags.WithConfigDirFlag(),
+			flags.WithSSHKeyFlag(),
+			flags.WithMetadataFlags(),
+			flags.WithNetworkFlags(),
+			flags.WithProtectionLabelFlag(),
+			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
+		),
+		Action: func(c *cli.Context) error {
+			return ServeFn(w, cfg)
+		},
+	}
+}
+
+// ServeFn serves the REST api on cfg.Listen until interrupted, proxying to
+// each server given as an argument, or the --grpc-address if there are none.
+// Microvms are created on the first server unless a request names another.
+//
+// Requests are made with cfg.Token, so anyone who can reach the api can act
+// with it. Without a token to check requests against, the api may only listen
+// on a loopback address.
+func ServeFn(w utils.Writer, cfg *config.Config) error {
+	if !utils.IsSet(cfg.Token) && !isLoopback(cfg.Listen) {
+		return exitcode.New(exitcode.Usage,
+			"refusing to serve on %s without --token, listen on a loopback address or set a token", cfg.Listen)
+	}
+
+	addresses := cfg.Args
+	if len(addresses) == 0 {
+		addresses = []string{cfg.GRPCAddress}
+	}
+
+	hosts := []gateway.Host{}
+
+	for _, address := range addresses {
+		client, err := cfg.ClientBuilderFunc(address, cfg.Token, cfg.SDKOptions()...)
+		if err != nil {
+			return err
+		}
+
+		defer client.Close()
+
+		hosts = append(hosts, gateway.Host{Address: address, Client: client})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	return listenAndServe(ctx, w, cfg.Listen, gateway.New(hosts, cfg, newMicroVM), "the REST api", "/")
+}
+
+// isLoopback reports whether address (a host:port) only accepts local
+// connections. An empty host listens on every interface, so is not.
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
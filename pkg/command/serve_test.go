@@ -0,0 +1,32 @@
+package command_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/command"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_ServeFn_refusesPublicListenWithoutToken(t *testing.T) {
+	for _, listen := range []string{":8080", "0.0.0.0:8080", "192.168.1.10:8080", "[::]:8080"} {
+		t.Run(listen, func(t *testing.T) {
+			g := NewWithT(t)
+
+			cfg := &config.Config{
+				ClientConfig: config.ClientConfig{
+					ClientBuilderFunc: testClient(nil, errors.New("dialled")),
+				},
+				Listen: listen,
+			}
+
+			err := command.ServeFn(utils.NewWriter(nil), cfg)
+			g.Expect(err).To(MatchError(ContainSubstring("without --token")))
+			g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Usage))
+		})
+	}
+}
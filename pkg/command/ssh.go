@@ -0,0 +1,179 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/resolver"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func sshCommand() *cli.Command {
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: client.New,
+		},
+	}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:      "ssh",
+		Usage:     "ssh into a microvm",
+		ArgsUsage: "<namespace/name|uid> [-- ssh args...]",
+		Before:    flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
+			flags.WithSSHFlags(),
+			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
+		),
+		Action: func(c *cli.Context) error {
+			return SSHFn(w, cfg)
+		},
+	}
+}
+
+func SSHFn(w utils.Writer, cfg *config.Config) error {
+	if len(cfg.Args) == 0 {
+		return exitcode.New(exitcode.Usage, "required: <namespace/name|uid>")
+	}
+
+	ref, err := resolver.Parse(cfg.Args[0])
+	if err != nil {
+		return err
+	}
+
+	client, err := cfg.ClientBuilderFunc(cfg.GRPCAddress, cfg.Token, cfg.SDKOptions()...)
+	if err != nil {
+		return err
+	}
+
+	defer client.Close()
+
+	mvm, err := resolver.New(client).One(ref)
+	if err != nil {
+		return err
+	}
+
+	address, err := guestAddress(mvm)
+	if err != nil {
+		return err
+	}
+
+	args := sshCommandArgs(cfg, address, passthroughArgs(cfg.Args[1:]))
+
+	if cfg.SSHPrint {
+		w.Print(shellJoin(append([]string{"ssh"}, args...)))
+
+		return nil
+	}
+
+	sshBin, err := exec.LookPath("ssh")
+	if err != nil {
+		return fmt.Errorf("could not find ssh binary: %w", err)
+	}
+
+	cmd := exec.Command(sshBin, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	if err := cmd.Run(); err != nil {
+		// Exit as ssh did, so scripts can tell its failures apart.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			return exitcode.New(exitErr.ExitCode(), "ssh exited with status %d", exitErr.ExitCode())
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// passthroughArgs drops the `--` which separates the extra ssh args from our
+// own, as urfave/cli leaves it in the args.
+func passthroughArgs(args []string) []string {
+	if len(args) > 0 && args[0] == "--" {
+		return args[1:]
+	}
+
+	return args
+}
+
+// guestAddress returns the first static address of an interface on the
+// microvm. Interfaces which flintlock has not reported as attached in the
+// status are skipped. Note that flintlock does not report DHCP leases, so only
+// statically addressed microvms can be found.
+func guestAddress(mvm *types.MicroVM) (string, error) {
+	var attached map[string]*types.NetworkInterfaceStatus
+	if mvm.Status != nil {
+		attached = mvm.Status.NetworkInterfaces
+	}
+
+	for _, iface := range mvm.Spec.Interfaces {
+		if iface.Address == nil {
+			continue
+		}
+
+		if len(attached) > 0 {
+			if _, ok := attached[iface.DeviceId]; !ok {
+				continue
+			}
+		}
+
+		ip, _, err := net.ParseCIDR(iface.Address.Address)
+		if err != nil {
+			return "", fmt.Errorf("interface %s: invalid static address %q: %w", iface.DeviceId, iface.Address.Address, err)
+		}
+
+		return ip.String(), nil
+	}
+
+	return "", fmt.Errorf("no guest address found for MicroVM %s/%s: it has no static address",
+		mvm.Spec.Namespace, mvm.Spec.Id)
+}
+
+func sshCommandArgs(cfg *config.Config, address string, extra []string) []string {
+	args := []string{}
+
+	if utils.IsSet(cfg.SSHIdentityFile) {
+		args = append(args, "-i", cfg.SSHIdentityFile)
+	}
+
+	if utils.IsSet(cfg.SSHUser) {
+		address = cfg.SSHUser + "@" + address
+	}
+
+	args = append(args, address)
+
+	return append(args, extra...)
+}
+
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+
+	for i, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\$`;&|<>*?()[]{}!#~") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+
+		quoted[i] = arg
+	}
+
+	return strings.Join(quoted, " ")
+}
@@ -0,0 +1,197 @@
+package command_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc"
+
+	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
+	"github.com/warehouse-13/hammertime/pkg/command"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_SSHFn_print_byUid(t *testing.T) {
+	g := NewWithT(t)
+
+	var testUid = "abc123"
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:            []string{testUid, "-L", "8080:localhost:80", "echo hello"},
+		SSHUser:         "root",
+		SSHIdentityFile: "/home/me/.ssh/id_ed25519",
+		SSHPrint:        true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := getResponse("foo", "bar", testUid)
+	resp.Microvm.Spec.Interfaces = []*types.NetworkInterface{
+		{DeviceId: "eth1"},
+		{DeviceId: "eth2", Address: &types.StaticAddress{Address: "192.168.100.10/24"}},
+	}
+	mockClient.GetReturns(resp, nil)
+
+	g.Expect(command.SSHFn(w, cfg)).To(Succeed())
+	g.Expect(mockClient.GetArgsForCall(0)).To(Equal(testUid))
+	g.Expect(buf.String()).To(Equal(
+		"ssh -i /home/me/.ssh/id_ed25519 root@192.168.100.10 -L 8080:localhost:80 'echo hello'\n",
+	))
+}
+
+func Test_SSHFn_print_byName(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args:     []string{"bar/foo"},
+		SSHUser:  "ubuntu",
+		SSHPrint: true,
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	resp := listResponse(1, "foo", "bar")
+	resp.Microvm[0].Spec.Interfaces = []*types.NetworkInterface{
+		{DeviceId: "eth1", Address: &types.StaticAddress{Address: "192.168.100.10/24"}},
+		{DeviceId: "eth2", Address: &types.StaticAddress{Address: "192.168.200.10/24"}},
+	}
+	resp.Microvm[0].Status.NetworkInterfaces = map[string]*types.NetworkInterfaceStatus{
+		"eth2": {HostDeviceName: "tap2"},
+	}
+	mockClient.ListReturns(resp, nil)
+
+	g.Expect(command.SSHFn(w, cfg)).To(Succeed())
+
+	name, ns := mockClient.ListArgsForCall(0)
+	g.Expect(name).To(Equal("foo"))
+	g.Expect(ns).To(Equal("bar"))
+	g.Expect(buf.String()).To(Equal("ssh ubuntu@192.168.200.10\n"))
+}
+
+func Test_SSHFn_noTarget(t *testing.T) {
+	g := NewWithT(t)
+
+	cfg := &config.Config{}
+
+	g.Expect(command.SSHFn(utils.NewWriter(nil), cfg)).To(MatchError("required: <namespace/name|uid>"))
+}
+
+func Test_SSHFn_notFound(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"bar/foo"},
+	}
+
+	mockClient.ListReturns(listResponse(0, "foo", "bar"), nil)
+
+	g.Expect(command.SSHFn(utils.NewWriter(nil), cfg)).To(MatchError("MicroVM bar/foo not found"))
+}
+
+func Test_SSHFn_multipleMatches(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"bar/foo"},
+	}
+
+	mockClient.ListReturns(listResponse(2, "foo", "bar"), nil)
+
+	g.Expect(command.SSHFn(utils.NewWriter(nil), cfg)).To(MatchError(ContainSubstring("2 MicroVMs found")))
+}
+
+func Test_SSHFn_noAddress(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Args: []string{"abc123"},
+	}
+
+	mockClient.GetReturns(getResponse("foo", "bar", "abc123"), nil)
+
+	g.Expect(command.SSHFn(utils.NewWriter(nil), cfg)).To(MatchError(ContainSubstring("no guest address found")))
+}
+
+// sshServer serves a single microvm with a static address.
+type sshServer struct {
+	v1alpha1.UnimplementedMicroVMServer
+}
+
+func (sshServer) GetMicroVM(_ context.Context, req *v1alpha1.GetMicroVMRequest) (*v1alpha1.GetMicroVMResponse, error) {
+	res := getResponse("foo", "bar", req.Uid)
+	res.Microvm.Spec.Interfaces = []*types.NetworkInterface{
+		{DeviceId: "eth1", Address: &types.StaticAddress{Address: "192.168.100.10/24"}},
+	}
+
+	return res, nil
+}
+
+func Test_SSH_app(t *testing.T) {
+	g := NewWithT(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	server := grpc.NewServer()
+	v1alpha1.RegisterMicroVMServer(server, sshServer{})
+
+	go server.Serve(listener) //nolint: errcheck // test server
+
+	t.Cleanup(server.Stop)
+
+	// A fake ssh which records its args and fails as ssh does when it cannot
+	// connect.
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := "#!/bin/sh\nprintf '%s\\n' \"$@\" > " + argsFile + "\nexit 255\n"
+	g.Expect(os.WriteFile(filepath.Join(dir, "ssh"), []byte(script), 0o755)).To(Succeed()) //nolint: gosec // test
+
+	t.Setenv("PATH", dir)
+
+	app := command.NewApp(&bytes.Buffer{})
+	app.ErrWriter = &bytes.Buffer{}
+
+	err = app.Run([]string{
+		"hammertime", "ssh", "--grpc-address", listener.Addr().String(), "--user", "root",
+		"abc123", "--", "-L", "8080:localhost:80",
+	})
+	g.Expect(err).To(MatchError("ssh exited with status 255"))
+	g.Expect(command.HandleError(app, err)).To(Equal(255))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(255))
+
+	args, err := os.ReadFile(argsFile)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(strings.Fields(string(args))).To(Equal([]string{"root@192.168.100.10", "-L", "8080:localhost:80"}))
+}
@@ -0,0 +1,132 @@
+package command
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/stats"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func statsCommand() *cli.Command {
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: client.New,
+		},
+	}
+
+	w := utils.NewWriter(os.Stdout)
+
+	return &cli.Command{
+		Name:      "stats",
+		Usage:     "total the microvms, vcpus, memory, volumes and images in use",
+		ArgsUsage: "[address...]",
+		Before:    flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
+			flags.WithNameAndNamespaceFlags(false),
+			flags.WithGroupByFlag(),
+			flags.WithOutputFlag(outputTable, outputJSON, outputPrometheus),
+			flags.WithBasicAuthFlag(),
+			flags.WithLogFlags(),
+		),
+		Action: func(c *cli.Context) error {
+			return StatsFn(w, cfg)
+		},
+	}
+}
+
+// StatsFn lists the microvms on each server given as an argument, or the
+// --grpc-address if there are none, and prints their totals grouped by
+// cfg.GroupBy.
+func StatsFn(w utils.Writer, cfg *config.Config) error {
+	if err := checkOutput(cfg, outputTable, outputJSON, outputPrometheus); err != nil {
+		return err
+	}
+
+	addresses := cfg.Args
+	if len(addresses) == 0 {
+		addresses = []string{cfg.GRPCAddress}
+	}
+
+	samples, err := listHosts(cfg, addresses)
+	if err != nil {
+		return err
+	}
+
+	groups := stats.Summarise(samples, cfg.GroupBy)
+
+	switch cfg.Output {
+	case outputJSON:
+		return w.PrettyPrint(groups)
+	case outputPrometheus:
+		w.Printf("%s", stats.Prometheus(cfg.GroupBy, groups))
+	default:
+		w.Printf("%s", stats.Table(cfg.GroupBy, groups))
+	}
+
+	return nil
+}
+
+// listHosts lists the microvms on every host at once. It fails if any host
+// does, as totals missing a host would be misleading.
+func listHosts(cfg *config.Config, addresses []string) ([]stats.Sample, error) {
+	var (
+		samples = make([][]stats.Sample, len(addresses))
+		errs    = make([]error, len(addresses))
+		wg      sync.WaitGroup
+	)
+
+	for i, address := range addresses {
+		wg.Add(1)
+
+		go func(i int, address string) {
+			defer wg.Done()
+
+			samples[i], errs[i] = listHost(cfg, address)
+		}(i, address)
+	}
+
+	wg.Wait()
+
+	out := []stats.Sample{}
+
+	for i, address := range addresses {
+		if errs[i] != nil {
+			return nil, fmt.Errorf("listing microvms on %s: %w", address, errs[i])
+		}
+
+		out = append(out, samples[i]...)
+	}
+
+	return out, nil
+}
+
+func listHost(cfg *config.Config, address string) ([]stats.Sample, error) {
+	client, err := cfg.ClientBuilderFunc(address, cfg.Token, cfg.SDKOptions()...)
+	if err != nil {
+		return nil, err
+	}
+
+	defer client.Close()
+
+	res, err := client.List(cfg.MvmName, cfg.MvmNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]stats.Sample, 0, len(res.GetMicrovm()))
+	for _, mvm := range res.GetMicrovm() {
+		samples = append(samples, stats.Sample{Host: address, MicroVM: mvm})
+	}
+
+	return samples, nil
+}
@@ -0,0 +1,86 @@
+package command_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
+	"github.com/warehouse-13/hammertime/pkg/command"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
+	"github.com/warehouse-13/hammertime/pkg/stats"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_StatsFn(t *testing.T) {
+	g := NewWithT(t)
+
+	hostA, hostB := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	hostA.ListReturns(listResponse(2, "foo", "bar"), nil)
+	hostB.ListReturns(listResponse(1, "foo", "baz"), nil)
+
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: hostClients(map[string]client.FlintlockClient{"a:9090": hostA, "b:9090": hostB}),
+		},
+		Args:    []string{"b:9090", "a:9090"},
+		GroupBy: []string{stats.ByHost, stats.ByNamespace},
+		Output:  "json",
+	}
+
+	buf := &bytes.Buffer{}
+	w := utils.NewWriter(buf)
+
+	g.Expect(command.StatsFn(w, cfg)).To(Succeed())
+
+	out := []stats.Group{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &out)).To(Succeed())
+	g.Expect(out).To(HaveLen(2))
+	g.Expect(out[0].Keys).To(Equal(map[string]string{"host": "a:9090", "namespace": "bar"}))
+	g.Expect(out[0].MicroVMs).To(Equal(2))
+	g.Expect(out[1].Keys).To(Equal(map[string]string{"host": "b:9090", "namespace": "baz"}))
+	g.Expect(out[1].MicroVMs).To(Equal(1))
+}
+
+func Test_StatsFn_hostFails(t *testing.T) {
+	g := NewWithT(t)
+
+	hostA, hostB := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	hostA.ListReturns(listResponse(2, "foo", "bar"), nil)
+	hostB.ListReturns(nil, errors.New("boom"))
+
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: hostClients(map[string]client.FlintlockClient{"a:9090": hostA, "b:9090": hostB}),
+		},
+		Args: []string{"a:9090", "b:9090"},
+	}
+
+	g.Expect(command.StatsFn(utils.NewWriter(nil), cfg)).To(MatchError("listing microvms on b:9090: boom"))
+}
+
+func Test_StatsFn_unsupportedOutput(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: testClient(mockClient, nil),
+		},
+		Output: "ndjson",
+	}
+
+	g.Expect(command.StatsFn(utils.NewWriter(nil), cfg)).To(MatchError("unsupported output format: ndjson"))
+	g.Expect(mockClient.ListCallCount()).To(BeZero())
+}
+
+func hostClients(clients map[string]client.FlintlockClient) clientBuilderFunc {
+	return func(address string, _ string, _ ...sdk.Option) (client.FlintlockClient, error) {
+		return clients[address], nil
+	}
+}
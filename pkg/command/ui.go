@@ -0,0 +1,68 @@
+package command
+
+import (
+	"os"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+	"github.com/warehouse-13/hammertime/pkg/tui"
+)
+
+func uiCommand() *cli.Command {
+	cfg := &config.Config{
+		ClientConfig: config.ClientConfig{
+			ClientBuilderFunc: client.New,
+		},
+	}
+
+	return &cli.Command{
+		Name:      "ui",
+		Usage:     "browse and manage microvms in an interactive terminal ui",
+		ArgsUsage: "[address...]",
+		Before:    flags.ParseFlags(cfg),
+		Flags: flags.CLIFlags(
+			flags.WithGRPCAddressFlag(),
+			flags.WithProxyFlags(),
+			flags.WithRetryFlags(),
+			flags.WithRefreshFlag(defaults.UIRefresh),
+			flags.WithProtectionLabelFlag(),
+			flags.WithBasicAuthFlag(),
+		),
+		Action: func(c *cli.Context) error {
+			return UIFn(cfg)
+		},
+	}
+}
+
+// UIFn connects to each server given as an argument, or the --grpc-address if
+// there are none, and runs the ui on the terminal.
+func UIFn(cfg *config.Config) error {
+	addresses := cfg.Args
+	if len(addresses) == 0 {
+		addresses = []string{cfg.GRPCAddress}
+	}
+
+	hosts := []tui.Host{}
+
+	for _, address := range addresses {
+		client, err := cfg.ClientBuilderFunc(address, cfg.Token, cfg.SDKOptions()...)
+		if err != nil {
+			return err
+		}
+
+		defer client.Close()
+
+		hosts = append(hosts, tui.Host{Address: address, Client: client})
+	}
+
+	refresh := cfg.Refresh
+	if refresh <= 0 {
+		refresh = defaults.UIRefresh
+	}
+
+	return tui.Run(tui.New(hosts, os.Stdout, cfg.ProtectionLabel), os.Stdin, os.Stdout, refresh)
+}
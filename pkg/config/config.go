@@ -1,7 +1,14 @@
 package config
 
 import (
+	"io"
+	"net/url"
+	"time"
+
+	"github.com/go-logr/logr"
+
 	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
 )
 
 type Config struct {
@@ -11,25 +18,147 @@ type Config struct {
 	MvmName string
 	// MvmNamespace is the namespace of the Microvm.
 	MvmNamespace string
-	// JSONFile is the path to a file containing a Microvm Spec in json.
+	// NoDefaults requires the name and namespace to be given rather than
+	// defaulting them. Can only be used with `create` and `get`.
+	NoDefaults bool
+	// JSONFile is the path to a file containing a Microvm Spec in json. The file
+	// may be a template, see TemplateValues.
 	JSONFile string
-	// SSHKeyPath is the path to a file containing a public key. Added for
-	// creating/using a Microvm with SSH access.
-	SSHKeyPath string
+	// TemplateValues are the values to render the JSONFile template with.
+	TemplateValues map[string]interface{}
+	// Preset is the name of the preset new Microvms are built from.
+	Preset string
+	// ConfigDir is the hammertime config directory, which presets are loaded
+	// from.
+	ConfigDir string
+	// SSHKeyPaths are paths to files containing public keys, one per line. Added
+	// for creating/using a Microvm with SSH access.
+	SSHKeyPaths []string
+	// SSHAgent adds the keys held by the running ssh-agent.
+	SSHAgent bool
+	// UserDataFile is the path to a file containing cloud-init user-data, either
+	// a `#cloud-config` document or multipart MIME.
+	UserDataFile string
+	// UserDataMerge merges the UserDataFile with the generated user-data rather
+	// than passing it through verbatim.
+	UserDataMerge bool
+	// Metadata holds additional keys to set in the instance meta-data.
+	Metadata map[string]string
+	// CloudName is the cloud name set in the instance meta-data.
+	CloudName string
+	// AvailabilityZone is the availability zone set in the instance meta-data.
+	AvailabilityZone string
+	// StaticAddress is a CIDR address to statically assign to the Microvm's
+	// default interface.
+	StaticAddress string
+	// Gateway is the gateway for the StaticAddress.
+	Gateway string
+	// Nameservers are the nameservers for the StaticAddress.
+	Nameservers []string
+	// NetworkConfig generates a cloud-init network-config from the Microvm's
+	// interfaces rather than leaving it to flintlock.
+	NetworkConfig bool
 	// State reports on only the state of a Microvm. Can only be used with `get`.
 	State bool
+	// ShowMetadata decodes the Microvm's metadata. Can only be used with `get`.
+	ShowMetadata bool
+	// Reveal shows secrets in decoded metadata. Can only be used with `get`.
+	Reveal bool
+	// Output is the format to print the response in. Can only be used with
+	// `create`, `get` and `list`.
+	Output string
 	// DeleteAll configures all microvms to be deleted. Can only be used with `delete`.
 	DeleteAll bool
+	// DryRun prints what would be created or deleted without doing it. Can only
+	// be used with `create` and `delete`.
+	DryRun bool
+	// Parallelism is the number of Microvms deleted at once. Can only be used
+	// with `delete`.
+	Parallelism int
+	// Yes skips the confirmation prompt. Can only be used with `delete`.
+	Yes bool
+	// Force deletes Microvms which carry the ProtectionLabel. Can only be used
+	// with `delete`.
+	Force bool
+	// ProtectionLabel is a `key=value` (or just `key`) label which protects
+	// Microvms from deletion. Can only be used with `delete`.
+	ProtectionLabel string
+	// Interactive is true when Stdin is a terminal which the user can answer
+	// prompts on.
+	Interactive bool
+	// Stdin is where answers to prompts are read from.
+	Stdin io.Reader
 	// Silent stops the response from being printed. Can only be used with `create` and `delete`.
 	Silent bool
 	// UUID is the id of a created Microvm.
 	UUID string
 	// Token used for basic auth
 	Token string
+	// Args are the positional arguments passed to the command.
+	Args []string
+	// SSHUser is the user to connect to a Microvm as. Can only be used with `ssh`.
+	SSHUser string
+	// SSHIdentityFile is the private key to connect to a Microvm with. Can only be
+	// used with `ssh`.
+	SSHIdentityFile string
+	// SSHPrint prints the ssh command rather than running it. Can only be used
+	// with `ssh`.
+	SSHPrint bool
+	// Refresh is how often the Microvms are listed again. Can only be used with
+	// `ui` and `exporter`.
+	Refresh time.Duration
+	// Listen is the address to serve http on. Can only be used with `exporter`
+	// and `serve`.
+	Listen string
+	// GroupBy is what the Microvms are totalled by: host, namespace, state or
+	// label:<key>. Can only be used with `stats`.
+	GroupBy []string
 
 	ClientConfig
 }
 
 type ClientConfig struct {
-	ClientBuilderFunc func(string, string) (client.FlintlockClient, error)
+	ClientBuilderFunc func(string, string, ...sdk.Option) (client.FlintlockClient, error)
+	// Retries is the number of times idempotent calls are retried after a
+	// transient failure.
+	Retries int
+	// RetryBackoff is the wait before the first retry, which doubles for each
+	// subsequent one.
+	RetryBackoff time.Duration
+	// Proxy is the SOCKS5 or HTTP proxy to connect to the server through.
+	Proxy *url.URL
+	// SSHJump is the host (eg. `user@bastion`) to tunnel the connection to the
+	// server through with ssh.
+	SSHJump string
+	// Verbosity is how much to log to stderr, 0 for nothing. See the logger
+	// package for the levels.
+	Verbosity int
+	// Logger is the logger built for the Verbosity.
+	Logger logr.Logger
+	// Timeout bounds connecting to and checking the server. Can only be used
+	// with `ping`.
+	Timeout time.Duration
+}
+
+// SDKOptions returns the options to build the client with.
+func (c ClientConfig) SDKOptions() []sdk.Option {
+	opts := []sdk.Option{}
+
+	if c.Retries > 0 {
+		opts = append(opts, sdk.WithRetries(c.Retries, c.RetryBackoff))
+	}
+
+	if c.Proxy != nil {
+		opts = append(opts, sdk.WithProxy(c.Proxy))
+	}
+
+	if c.SSHJump != "" {
+		opts = append(opts, sdk.WithSSHJump(c.SSHJump))
+	}
+
+	if c.Verbosity > 0 {
+		opts = append(opts, sdk.WithLogger(c.Logger))
+	}
+
+	return opts
 }
@@ -1,6 +1,8 @@
 package defaults
 
 import (
+	"time"
+
 	"github.com/weaveworks-liquidmetal/flintlock/api/types"
 	"k8s.io/utils/pointer"
 )
@@ -13,6 +15,33 @@ const (
 	MvmName = "mvm0"
 	// MvmNamespace is the default name to use when creating a Microvm.
 	MvmNamespace = "ns0"
+	// SSHUser is the default user to connect to a Microvm as.
+	SSHUser = "root"
+	// ProtectionLabel is the default label which protects a Microvm from
+	// deletion.
+	ProtectionLabel = "hammertime.io/protected=true"
+	// Retries is the default number of times idempotent calls are retried.
+	Retries = 3
+	// RetryBackoff is the default wait before the first retry.
+	RetryBackoff = 250 * time.Millisecond
+	// PingTimeout is the default time allowed to connect to and check a server.
+	PingTimeout = 5 * time.Second
+	// DeleteParallelism is the default number of Microvms deleted at once.
+	DeleteParallelism = 5
+	// CompletionTimeout bounds the calls made to complete flag values, so that
+	// an unreachable server does not hang the shell.
+	CompletionTimeout = 2 * time.Second
+	// UIRefresh is the default interval at which the ui lists the Microvms
+	// again.
+	UIRefresh = 2 * time.Second
+	// ExporterRefresh is the default interval at which the exporter lists the
+	// Microvms again.
+	ExporterRefresh = 30 * time.Second
+	// ExporterListen is the default address the exporter serves metrics on.
+	ExporterListen = ":9100"
+	// ServeListen is the default address the REST gateway serves on. It is
+	// local only, as the gateway can create and delete Microvms.
+	ServeListen = "127.0.0.1:8080"
 )
 
 const (
@@ -27,6 +56,7 @@ const (
 	modulesPath    = "/lib/modules/5.10.77"
 )
 
+// BaseMicroVM returns the spec of the built-in `default` preset.
 func BaseMicroVM() *types.MicroVMSpec {
 	return &types.MicroVMSpec{
 		Vcpu:       2,    //nolint: gomnd // we don't care
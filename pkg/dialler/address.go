@@ -0,0 +1,178 @@
+package dialler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/resolver"
+)
+
+const (
+	// UnixScheme is the scheme of unix domain socket addresses, eg.
+	// unix:///var/run/flintlock.sock.
+	UnixScheme = "unix"
+	// SRVScheme is the scheme of addresses which are resolved by looking up
+	// DNS SRV records, eg. dns+srv://_flintlock._tcp.example.internal.
+	SRVScheme = "dns+srv"
+
+	roundRobinConfig = `{"loadBalancingConfig":[{"round_robin":{}}]}`
+
+	addressFormats = "host:port, unix:///path/to/socket or dns+srv://_service._proto.domain"
+)
+
+// lookupSRV is swapped out in tests.
+var lookupSRV = net.DefaultResolver.LookupSRV
+
+// ValidateAddress checks that address is one which hammertime knows how to
+// dial: host:port, unix:///path/to/socket (or unix:relative/path) or
+// dns+srv://_service._proto.domain.
+func ValidateAddress(address string) error {
+	if address == "" {
+		return fmt.Errorf("address is empty, expected %s", addressFormats)
+	}
+
+	scheme, rest, hasScheme := strings.Cut(address, "://")
+	if !hasScheme {
+		if path := strings.TrimPrefix(address, UnixScheme+":"); path != address {
+			if path == "" {
+				return fmt.Errorf("invalid address %q: missing unix socket path", address)
+			}
+
+			return nil
+		}
+
+		return validateHostPort(address)
+	}
+
+	switch scheme {
+	case UnixScheme:
+		if !strings.HasPrefix(rest, "/") {
+			return fmt.Errorf(
+				"invalid address %q: unix socket paths must be absolute, eg. unix:///path/to/socket", address,
+			)
+		}
+	case SRVScheme:
+		if rest == "" || strings.ContainsAny(rest, ":/") {
+			return fmt.Errorf(
+				"invalid address %q: expected a DNS SRV name, eg. dns+srv://_flintlock._tcp.example.internal", address,
+			)
+		}
+	default:
+		return fmt.Errorf("invalid address %q: unsupported scheme %q, expected %s", address, scheme, addressFormats)
+	}
+
+	return nil
+}
+
+func validateHostPort(address string) error {
+	_, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return fmt.Errorf("invalid address %q: expected %s", address, addressFormats)
+	}
+
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return fmt.Errorf("invalid address %q: port must be a number between 1 and 65535", address)
+	}
+
+	return nil
+}
+
+// IsUnix reports whether address is a unix domain socket.
+func IsUnix(address string) bool {
+	return strings.HasPrefix(address, UnixScheme+":")
+}
+
+// IsLoadBalanced reports whether address may resolve to more than one server.
+func IsLoadBalanced(address string) bool {
+	return strings.HasPrefix(address, SRVScheme+"://")
+}
+
+// WithRoundRobin returns a DialOption which spreads calls across all of the
+// addresses the target resolves to, rather than sticking to the first.
+func WithRoundRobin() grpc.DialOption {
+	return grpc.WithDefaultServiceConfig(roundRobinConfig)
+}
+
+// srvBuilder builds resolvers for dns+srv:// targets.
+type srvBuilder struct{}
+
+func (srvBuilder) Build(
+	target resolver.Target, cc resolver.ClientConn, _ resolver.BuildOptions,
+) (resolver.Resolver, error) {
+	name := target.URL.Host
+	if name == "" {
+		name = strings.TrimPrefix(target.URL.Path, "/")
+	}
+
+	if name == "" {
+		return nil, fmt.Errorf("missing DNS SRV name in %q", target.URL.String())
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	r := &srvResolver{name: name, cc: cc, ctx: ctx, cancel: cancel}
+	r.resolve()
+
+	return r, nil
+}
+
+func (srvBuilder) Scheme() string {
+	return SRVScheme
+}
+
+// srvResolver resolves a name to the hosts in its SRV records. Records are
+// kept in the order returned by the lookup (by priority, then randomised by
+// weight), so the first address is the preferred server.
+type srvResolver struct {
+	name   string
+	cc     resolver.ClientConn
+	ctx    context.Context //nolint: containedctx // cancelled on Close
+	cancel context.CancelFunc
+	mu     sync.Mutex
+}
+
+func (r *srvResolver) ResolveNow(resolver.ResolveNowOptions) {
+	go r.resolve()
+}
+
+func (r *srvResolver) Close() {
+	r.cancel()
+}
+
+func (r *srvResolver) resolve() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, records, err := lookupSRV(r.ctx, "", "", r.name)
+	if err == nil && len(records) == 0 {
+		err = errors.New("no records found")
+	}
+
+	if err != nil {
+		if r.ctx.Err() == nil {
+			r.cc.ReportError(fmt.Errorf("resolving SRV records for %s: %w", r.name, err))
+		}
+
+		return
+	}
+
+	addresses := make([]resolver.Address, 0, len(records))
+
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+
+		addresses = append(addresses, resolver.Address{
+			Addr: net.JoinHostPort(host, strconv.Itoa(int(record.Port))),
+		})
+	}
+
+	if err := r.cc.UpdateState(resolver.State{Addresses: addresses}); err != nil {
+		r.cc.ReportError(err)
+	}
+}
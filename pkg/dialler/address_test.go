@@ -0,0 +1,156 @@
+package dialler_test
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"google.golang.org/grpc"
+
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+)
+
+func Test_ValidateAddress(t *testing.T) {
+	tt := []struct {
+		address string
+		err     string
+	}{
+		{address: "127.0.0.1:9090"},
+		{address: "flintlock.example.internal:9090"},
+		{address: "[::1]:9090"},
+		{address: "unix:///var/run/flintlock.sock"},
+		{address: "unix:flintlock.sock"},
+		{address: "dns+srv://_flintlock._tcp.example.internal"},
+		{address: "", err: "address is empty"},
+		{address: "127.0.0.1", err: "expected host:port, unix:///path/to/socket or dns+srv://"},
+		{address: "127.0.0.1:http", err: "port must be a number"},
+		{address: "127.0.0.1:70000", err: "port must be a number"},
+		{address: "unix://flintlock.sock", err: "unix socket paths must be absolute"},
+		{address: "unix:", err: "missing unix socket path"},
+		{address: "dns+srv://", err: "expected a DNS SRV name"},
+		{address: "dns+srv://_flintlock._tcp.example.internal:9090", err: "expected a DNS SRV name"},
+		{address: "https://example.internal:9090", err: `unsupported scheme "https"`},
+	}
+
+	for _, tc := range tt {
+		tc := tc
+
+		t.Run(tc.address, func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := dialler.ValidateAddress(tc.address)
+			if tc.err == "" {
+				g.Expect(err).NotTo(HaveOccurred())
+
+				return
+			}
+
+			g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+		})
+	}
+}
+
+func Test_New_unixSocket(t *testing.T) {
+	g := NewWithT(t)
+
+	socket := filepath.Join(t.TempDir(), "flintlock.sock")
+
+	listener, err := net.Listen("unix", socket)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	server := &flakyServer{}
+	grpcServer := grpc.NewServer()
+	v1alpha1.RegisterMicroVMServer(grpcServer, server)
+
+	go grpcServer.Serve(listener) //nolint: errcheck // stopped in cleanup
+
+	t.Cleanup(grpcServer.Stop)
+
+	getThrough(t, "unix://"+socket)
+	g.Expect(server.calls).To(Equal(int32(1)))
+}
+
+func Test_New_dnsSRV(t *testing.T) {
+	g := NewWithT(t)
+
+	first, second := &flakyServer{}, &flakyServer{}
+	records := []*net.SRV{srvRecord(t, startFlakyTCP(t, first)), srvRecord(t, startFlakyTCP(t, second))}
+
+	var looked string
+
+	t.Cleanup(dialler.SetLookupSRV(func(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+		looked = name
+
+		return name, records, nil
+	}))
+
+	// Without load balancing every call goes to the first record
+	gets(t, "dns+srv://_flintlock._tcp.example.internal", 4)
+	g.Expect(looked).To(Equal("_flintlock._tcp.example.internal"))
+	g.Expect(first.calls).To(Equal(int32(4)))
+	g.Expect(second.calls).To(BeZero())
+
+	// With it calls are spread across both, once they are connected
+	conn, err := dialler.New("dns+srv://_flintlock._tcp.example.internal", "", []grpc.DialOption{dialler.WithRoundRobin()})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	defer conn.Close()
+
+	client := v1alpha1.NewMicroVMClient(conn)
+
+	g.Eventually(func() int32 {
+		_, err := client.GetMicroVM(context.Background(), &v1alpha1.GetMicroVMRequest{Uid: "abc"})
+		g.Expect(err).NotTo(HaveOccurred())
+
+		return atomic.LoadInt32(&second.calls)
+	}).Should(BeNumerically(">", 0))
+}
+
+func Test_New_dnsSRV_noRecords(t *testing.T) {
+	g := NewWithT(t)
+
+	t.Cleanup(dialler.SetLookupSRV(func(_ context.Context, _, _, name string) (string, []*net.SRV, error) {
+		return name, nil, nil
+	}))
+
+	conn, err := dialler.New("dns+srv://_flintlock._tcp.example.internal", "", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	defer conn.Close()
+
+	_, err = v1alpha1.NewMicroVMClient(conn).GetMicroVM(context.Background(), &v1alpha1.GetMicroVMRequest{Uid: "abc"})
+	g.Expect(err).To(MatchError(ContainSubstring("resolving SRV records for _flintlock._tcp.example.internal")))
+}
+
+func gets(t *testing.T, address string, n int, opts ...grpc.DialOption) {
+	g := NewWithT(t)
+
+	conn, err := dialler.New(address, "", opts)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	defer conn.Close()
+
+	client := v1alpha1.NewMicroVMClient(conn)
+
+	for i := 0; i < n; i++ {
+		_, err = client.GetMicroVM(context.Background(), &v1alpha1.GetMicroVMRequest{Uid: "abc"})
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+func srvRecord(t *testing.T, address string) *net.SRV {
+	g := NewWithT(t)
+
+	host, port, err := net.SplitHostPort(address)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	n, err := strconv.Atoi(port)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	return &net.SRV{Target: host + ".", Port: uint16(n)}
+}
@@ -1,6 +1,8 @@
 package dialler
 
 import (
+	"context"
+
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
@@ -8,12 +10,22 @@ import (
 // New process the dial config and returns a grpc.ClientConn. The caller is
 // responsible for closing the connection.
 func New(address, basicAuthToken string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
-	// TODO this needs to be tidied up when adding TLS #47
-	dialOpts := opts
+	return NewWithContext(context.Background(), address, basicAuthToken, opts)
+}
 
-	dialOpts = append(dialOpts,
+// NewWithContext is New, with a context which bounds a blocking dial (see
+// grpc.WithBlock).
+func NewWithContext(
+	ctx context.Context, address, basicAuthToken string, opts []grpc.DialOption,
+) (*grpc.ClientConn, error) {
+	// The defaults come first so that opts can override them, eg. with
+	// grpc.WithTransportCredentials for TLS.
+	dialOpts := []grpc.DialOption{
 		grpc.WithTransportCredentials(insecure.NewCredentials()),
-	)
+		grpc.WithResolvers(srvBuilder{}),
+	}
+
+	dialOpts = append(dialOpts, opts...)
 
 	if basicAuthToken != "" {
 		dialOpts = append(dialOpts, grpc.WithPerRPCCredentials(
@@ -21,7 +33,8 @@ func New(address, basicAuthToken string, opts []grpc.DialOption) (*grpc.ClientCo
 		))
 	}
 
-	return grpc.Dial(
+	return grpc.DialContext(
+		ctx,
 		address,
 		dialOpts...,
 	)
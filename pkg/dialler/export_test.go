@@ -0,0 +1,16 @@
+package dialler
+
+import (
+	"context"
+	"net"
+)
+
+// SetLookupSRV replaces the SRV lookup for the duration of a test.
+func SetLookupSRV(lookup func(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)) func() {
+	orig := lookupSRV
+	lookupSRV = lookup
+
+	return func() {
+		lookupSRV = orig
+	}
+}
@@ -0,0 +1,63 @@
+package dialler
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// WithLogging returns a DialOption which logs each call, see LoggingInterceptor.
+func WithLogging(log logr.Logger) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(LoggingInterceptor(log))
+}
+
+// LoggingInterceptor returns an interceptor which logs the target, method,
+// latency and status code of each call, and at V(1) the request and response
+// payloads. Credentials are sent as call metadata so never appear in the log.
+func LoggingInterceptor(log logr.Logger) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		log := log.WithValues("target", cc.Target(), "method", method)
+		log.V(1).Info("request", "payload", payload(req))
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		log.Info("call", "latency", time.Since(start).String(), "code", status.Code(err).String())
+
+		if err != nil {
+			log.V(1).Info("error", "message", status.Convert(err).Message())
+
+			return err
+		}
+
+		log.V(1).Info("response", "payload", payload(reply))
+
+		return nil
+	}
+}
+
+func payload(msg interface{}) string {
+	pb, ok := msg.(proto.Message)
+	if !ok {
+		return ""
+	}
+
+	out, err := protojson.Marshal(pb)
+	if err != nil {
+		return err.Error()
+	}
+
+	return string(out)
+}
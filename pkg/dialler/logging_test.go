@@ -0,0 +1,51 @@
+package dialler_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"google.golang.org/grpc/codes"
+
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+	"github.com/warehouse-13/hammertime/pkg/logger"
+)
+
+func Test_LoggingInterceptor(t *testing.T) {
+	g := NewWithT(t)
+
+	buf := &bytes.Buffer{}
+	server := &flakyServer{}
+	client := startFlaky(t, server, dialler.WithLogging(logger.New(buf, logger.Calls)))
+
+	_, err := client.GetMicroVM(context.Background(), &v1alpha1.GetMicroVMRequest{Uid: "abc123"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(buf.String()).To(ContainSubstring(`"method"="/microvm.services.api.v1alpha1.MicroVM/GetMicroVM"`))
+	g.Expect(buf.String()).To(ContainSubstring(`"target"="bufnet"`))
+	g.Expect(buf.String()).To(ContainSubstring(`"code"="OK"`))
+	g.Expect(buf.String()).To(ContainSubstring(`"latency"=`))
+	g.Expect(buf.String()).NotTo(ContainSubstring("abc123"))
+}
+
+func Test_LoggingInterceptor_payloads(t *testing.T) {
+	g := NewWithT(t)
+
+	buf := &bytes.Buffer{}
+	server := &flakyServer{code: codes.Unavailable, failures: 1}
+	client := startFlaky(t, server,
+		dialler.WithRetries(1, 0),
+		dialler.WithLogging(logger.New(buf, logger.Payloads)),
+	)
+
+	_, err := client.GetMicroVM(context.Background(), &v1alpha1.GetMicroVMRequest{Uid: "abc123"})
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(buf.String()).To(ContainSubstring(`"msg"="request"`))
+	g.Expect(buf.String()).To(ContainSubstring(`abc123`))
+	g.Expect(buf.String()).To(ContainSubstring(`"code"="Unavailable"`))
+	g.Expect(buf.String()).To(MatchRegexp(`"msg"="error" .*"message"="flaky"`))
+	g.Expect(buf.String()).To(ContainSubstring(`"msg"="response"`))
+}
@@ -0,0 +1,121 @@
+package dialler
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang.org/x/net/proxy"
+	"google.golang.org/grpc"
+)
+
+// ParseProxyURL parses and checks a --proxy URL. socks5://, socks5h:// and
+// http:// proxies are supported, with optional user:password credentials.
+func ParseProxyURL(raw string) (*url.URL, error) {
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy %q: %w", raw, err)
+	}
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h", "http":
+	default:
+		return nil, fmt.Errorf("invalid proxy %q: scheme must be one of socks5, socks5h or http", raw)
+	}
+
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy %q: missing host", raw)
+	}
+
+	return proxyURL, nil
+}
+
+// WithProxy returns a DialOption which connects to the server through the
+// proxy. HTTP proxies are sent a CONNECT request.
+func WithProxy(proxyURL *url.URL) grpc.DialOption {
+	return grpc.WithContextDialer(func(ctx context.Context, address string) (net.Conn, error) {
+		if proxyURL.Scheme == "http" {
+			return dialHTTPProxy(ctx, proxyURL, address)
+		}
+
+		dialer, err := proxy.FromURL(proxyURL, proxy.Direct)
+		if err != nil {
+			return nil, err
+		}
+
+		return dialer.(proxy.ContextDialer).DialContext(ctx, "tcp", address) //nolint: forcetypeassert // socks always is
+	})
+}
+
+func dialHTTPProxy(ctx context.Context, proxyURL *url.URL, address string) (net.Conn, error) {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, "tcp", proxyURL.Host)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to proxy %s: %w", proxyURL.Host, err)
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: http.Header{},
+	}
+
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		creds := base64.StdEncoding.EncodeToString([]byte(proxyURL.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+creds)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+
+		defer conn.SetDeadline(time.Time{}) //nolint: errcheck // best effort
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("sending CONNECT to proxy %s: %w", proxyURL.Host, err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+
+		return nil, fmt.Errorf("reading CONNECT response from proxy %s: %w", proxyURL.Host, err)
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+
+		return nil, fmt.Errorf("proxy %s refused CONNECT to %s: %s", proxyURL.Host, address, resp.Status)
+	}
+
+	if reader.Buffered() > 0 {
+		return &bufferedConn{Conn: conn, reader: reader}, nil
+	}
+
+	return conn, nil
+}
+
+// bufferedConn reads any bytes the proxy sent after its response before
+// reading from the connection itself.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b)
+}
@@ -0,0 +1,81 @@
+package dialler
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// idempotentMethods are the calls which are safe to repeat. Deleting by UID
+// twice is fine: the second call finds nothing to delete.
+var idempotentMethods = map[string]bool{
+	"/microvm.services.api.v1alpha1.MicroVM/GetMicroVM":    true,
+	"/microvm.services.api.v1alpha1.MicroVM/ListMicroVMs":  true,
+	"/microvm.services.api.v1alpha1.MicroVM/DeleteMicroVM": true,
+}
+
+// WithRetries returns a DialOption which retries idempotent calls which fail
+// with a transient error, see RetryInterceptor.
+func WithRetries(retries int, backoff time.Duration) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(RetryInterceptor(retries, backoff))
+}
+
+// RetryInterceptor returns an interceptor which retries idempotent calls (Get,
+// List and Delete) up to retries times when they fail with Unavailable or
+// DeadlineExceeded. The wait between attempts starts at backoff and doubles
+// each time, with jitter so that many clients do not retry in lockstep.
+func RetryInterceptor(retries int, backoff time.Duration) grpc.UnaryClientInterceptor {
+	return func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !idempotentMethods[method] {
+			return err
+		}
+
+		wait := backoff
+
+		for attempt := 0; attempt < retries && isTransient(err); attempt++ {
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(jitter(wait)):
+			}
+
+			wait *= 2
+
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+
+		return err
+	}
+}
+
+func isTransient(err error) bool {
+	switch status.Code(err) { //nolint: exhaustive // nothing else is worth retrying
+	case codes.Unavailable, codes.DeadlineExceeded:
+		return true
+	default:
+		return false
+	}
+}
+
+// jitter returns a random duration between half and all of d.
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return 0
+	}
+
+	half := d / 2 //nolint: gomnd // half
+
+	return half + time.Duration(rand.Int63n(int64(d-half)+1)) //nolint: gosec // not for security
+}
@@ -0,0 +1,131 @@
+package dialler_test
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+)
+
+// flakyServer fails every call with code until it has failed failures times.
+type flakyServer struct {
+	v1alpha1.UnimplementedMicroVMServer
+
+	code     codes.Code
+	failures int32
+	calls    int32
+}
+
+func (s *flakyServer) fail() error {
+	if atomic.AddInt32(&s.calls, 1) <= s.failures {
+		return status.Error(s.code, "flaky")
+	}
+
+	return nil
+}
+
+func (s *flakyServer) GetMicroVM(context.Context, *v1alpha1.GetMicroVMRequest) (*v1alpha1.GetMicroVMResponse, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+
+	return &v1alpha1.GetMicroVMResponse{Microvm: &types.MicroVM{}}, nil
+}
+
+func (s *flakyServer) DeleteMicroVM(context.Context, *v1alpha1.DeleteMicroVMRequest) (*emptypb.Empty, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *flakyServer) CreateMicroVM(
+	context.Context, *v1alpha1.CreateMicroVMRequest,
+) (*v1alpha1.CreateMicroVMResponse, error) {
+	if err := s.fail(); err != nil {
+		return nil, err
+	}
+
+	return &v1alpha1.CreateMicroVMResponse{Microvm: &types.MicroVM{}}, nil
+}
+
+func Test_RetryInterceptor(t *testing.T) {
+	g := NewWithT(t)
+
+	server := &flakyServer{code: codes.Unavailable, failures: 2}
+	client := startFlaky(t, server, dialler.WithRetries(3, time.Millisecond))
+
+	_, err := client.GetMicroVM(context.Background(), &v1alpha1.GetMicroVMRequest{Uid: "abc"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(server.calls).To(Equal(int32(3)))
+}
+
+func Test_RetryInterceptor_givesUp(t *testing.T) {
+	g := NewWithT(t)
+
+	server := &flakyServer{code: codes.DeadlineExceeded, failures: 5}
+	client := startFlaky(t, server, dialler.WithRetries(2, time.Millisecond))
+
+	_, err := client.DeleteMicroVM(context.Background(), &v1alpha1.DeleteMicroVMRequest{Uid: "abc"})
+	g.Expect(status.Code(err)).To(Equal(codes.DeadlineExceeded))
+	g.Expect(server.calls).To(Equal(int32(3)))
+}
+
+func Test_RetryInterceptor_notTransient(t *testing.T) {
+	g := NewWithT(t)
+
+	server := &flakyServer{code: codes.NotFound, failures: 1}
+	client := startFlaky(t, server, dialler.WithRetries(3, time.Millisecond))
+
+	_, err := client.GetMicroVM(context.Background(), &v1alpha1.GetMicroVMRequest{Uid: "abc"})
+	g.Expect(status.Code(err)).To(Equal(codes.NotFound))
+	g.Expect(server.calls).To(Equal(int32(1)))
+}
+
+func Test_RetryInterceptor_notIdempotent(t *testing.T) {
+	g := NewWithT(t)
+
+	server := &flakyServer{code: codes.Unavailable, failures: 1}
+	client := startFlaky(t, server, dialler.WithRetries(3, time.Millisecond))
+
+	_, err := client.CreateMicroVM(context.Background(), &v1alpha1.CreateMicroVMRequest{})
+	g.Expect(status.Code(err)).To(Equal(codes.Unavailable))
+	g.Expect(server.calls).To(Equal(int32(1)))
+}
+
+func startFlaky(t *testing.T, server v1alpha1.MicroVMServer, opts ...grpc.DialOption) v1alpha1.MicroVMClient {
+	g := NewWithT(t)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	v1alpha1.RegisterMicroVMServer(grpcServer, server)
+
+	go grpcServer.Serve(listener) //nolint: errcheck // test server
+
+	opts = append(opts, grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+		return listener.Dial()
+	}))
+
+	conn, err := dialler.New("bufnet", "", opts)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Cleanup(func() {
+		conn.Close()
+		grpcServer.Stop()
+	})
+
+	return v1alpha1.NewMicroVMClient(conn)
+}
@@ -0,0 +1,63 @@
+package dialler_test
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+)
+
+// TestMain lets the test binary stand in for `ssh -W host:port jump` when it is
+// run through a symlink named ssh.
+func TestMain(m *testing.M) {
+	if filepath.Base(os.Args[0]) == "ssh" {
+		fakeSSH(os.Args[1:])
+	}
+
+	os.Exit(m.Run())
+}
+
+func fakeSSH(args []string) {
+	if len(args) != 3 || args[0] != "-W" { //nolint: gomnd // -W host:port jump
+		os.Exit(255) //nolint: gomnd // ssh's error code
+	}
+
+	if err := os.WriteFile(os.Getenv("FAKE_SSH_RECORD"), []byte(args[2]), 0o600); err != nil {
+		os.Exit(255) //nolint: gomnd // ssh's error code
+	}
+
+	conn, err := net.Dial("tcp", args[1])
+	if err != nil {
+		os.Exit(255) //nolint: gomnd // ssh's error code
+	}
+
+	go io.Copy(conn, os.Stdin) //nolint: errcheck // fake tunnel
+
+	io.Copy(os.Stdout, conn) //nolint: errcheck // fake tunnel
+	os.Exit(0)
+}
+
+func Test_WithSSHJump(t *testing.T) {
+	g := NewWithT(t)
+
+	address := startFlakyTCP(t, &flakyServer{})
+
+	binDir := t.TempDir()
+	g.Expect(os.Symlink(os.Args[0], filepath.Join(binDir, "ssh"))).To(Succeed())
+
+	record := filepath.Join(t.TempDir(), "jump")
+
+	t.Setenv("PATH", binDir)
+	t.Setenv("FAKE_SSH_RECORD", record)
+
+	getThrough(t, address, dialler.WithSSHJump("me@bastion"))
+
+	jump, err := os.ReadFile(record)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(jump)).To(Equal("me@bastion"))
+}
@@ -0,0 +1,26 @@
+package dialler
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+)
+
+// WithCallTimeout returns a DialOption which gives up on any call which takes
+// longer than timeout, with DeadlineExceeded.
+func WithCallTimeout(timeout time.Duration) grpc.DialOption {
+	return grpc.WithChainUnaryInterceptor(func(
+		ctx context.Context,
+		method string,
+		req, reply interface{},
+		cc *grpc.ClientConn,
+		invoker grpc.UnaryInvoker,
+		opts ...grpc.CallOption,
+	) error {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	})
+}
@@ -0,0 +1,39 @@
+package dialler_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+)
+
+// slowServer never answers, until the call is cancelled.
+type slowServer struct {
+	v1alpha1.UnimplementedMicroVMServer
+}
+
+func (*slowServer) ListMicroVMs(
+	ctx context.Context, _ *v1alpha1.ListMicroVMsRequest,
+) (*v1alpha1.ListMicroVMsResponse, error) {
+	<-ctx.Done()
+
+	return nil, ctx.Err()
+}
+
+func Test_WithCallTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	client := startFlaky(t, &slowServer{}, dialler.WithCallTimeout(50*time.Millisecond))
+
+	start := time.Now()
+
+	_, err := client.ListMicroVMs(context.Background(), &v1alpha1.ListMicroVMsRequest{})
+	g.Expect(status.Code(err)).To(Equal(codes.DeadlineExceeded))
+	g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+}
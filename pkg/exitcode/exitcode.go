@@ -0,0 +1,163 @@
+package exitcode
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Exit codes returned by hammertime. These are part of the CLI's interface so
+// that scripts can tell failures apart: do not renumber them.
+const (
+	// OK is returned when the command succeeded.
+	OK = 0
+	// Failure is returned for any error which does not have a more specific code.
+	Failure = 1
+	// Usage is returned for invalid flags or arguments, or a request flintlock
+	// rejected as invalid.
+	Usage = 2
+	// NotFound is returned when a Microvm (or other resource) does not exist.
+	NotFound = 3
+	// AlreadyExists is returned when creating a Microvm which already exists.
+	AlreadyExists = 4
+	// Unauthenticated is returned when flintlock rejects the token, or it is
+	// missing.
+	Unauthenticated = 5
+	// Unavailable is returned when the flintlock server cannot be reached or did
+	// not answer in time.
+	Unavailable = 6
+	// ServerError is returned when flintlock failed to process the request.
+	ServerError = 7
+	// Protected is returned when refusing to delete Microvms which carry the
+	// protection label.
+	Protected = 8
+)
+
+const (
+	// TextFormat writes errors as `Error: <message>`.
+	TextFormat = "text"
+	// JSONFormat writes errors as a JSON object with code, message and details.
+	JSONFormat = "json"
+)
+
+// Error is an error with the exit code hammertime should exit with.
+type Error struct {
+	// Code is the exit code.
+	Code int `json:"code"`
+	// Message is a human friendly description of the error.
+	Message string `json:"message"`
+	// Details holds extra context, eg. the gRPC status and the server's message.
+	Details []string `json:"details,omitempty"`
+	// Err is the underlying error, if any.
+	Err error `json:"-"`
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+
+	return fmt.Sprintf("%s: %s", e.Message, errorDesc(e.Err))
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an Error with the given exit code and formatted message.
+func New(code int, format string, args ...interface{}) error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// FromError returns err as an Error. gRPC status errors are given the exit
+// code and message for their status code; other errors not already an Error
+// get the Failure code.
+func FromError(err error) *Error {
+	var exitErr *Error
+	if errors.As(err, &exitErr) {
+		return exitErr
+	}
+
+	var grpcErr interface{ GRPCStatus() *status.Status }
+	if !errors.As(err, &grpcErr) {
+		return &Error{Code: Failure, Message: err.Error()}
+	}
+
+	st := grpcErr.GRPCStatus()
+	code, message := forStatus(st.Code())
+
+	details := []string{"grpc status: " + st.Code().String()}
+	if st.Message() != "" {
+		details = append(details, "server message: "+st.Message())
+	}
+
+	for _, detail := range st.Details() {
+		details = append(details, fmt.Sprintf("%v", detail))
+	}
+
+	return &Error{
+		Code:    code,
+		Message: message,
+		Details: details,
+		Err:     err,
+	}
+}
+
+// Write writes err to w in the given format (TextFormat if empty) and returns
+// the code to exit with.
+func Write(w io.Writer, err error, format string) int {
+	exitErr := FromError(err)
+
+	if format == JSONFormat {
+		out, jsonErr := json.Marshal(exitErr)
+		if jsonErr == nil {
+			fmt.Fprintf(w, "%s\n", out)
+
+			return exitErr.Code
+		}
+	}
+
+	fmt.Fprintf(w, "Error: %s\n", exitErr)
+
+	return exitErr.Code
+}
+
+func forStatus(code codes.Code) (int, string) {
+	switch code { //nolint: exhaustive // everything else is a ServerError
+	case codes.NotFound:
+		return NotFound, "not found"
+	case codes.AlreadyExists:
+		return AlreadyExists, "already exists"
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return Usage, "invalid request"
+	case codes.Unauthenticated:
+		return Unauthenticated, "authentication failed, check --token"
+	case codes.PermissionDenied:
+		return Unauthenticated, "permission denied"
+	case codes.Unavailable:
+		return Unavailable, "could not reach the flintlock server, check --grpc-address"
+	case codes.DeadlineExceeded:
+		return Unavailable, "timed out waiting for the flintlock server"
+	case codes.Canceled:
+		return Failure, "request cancelled"
+	default:
+		return ServerError, "flintlock server error"
+	}
+}
+
+// errorDesc returns the message of a gRPC status error without the `rpc error:
+// code = ... desc =` prefix.
+func errorDesc(err error) string {
+	if st, ok := status.FromError(err); ok && st.Message() != "" {
+		return st.Message()
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,90 @@
+package exitcode_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+)
+
+func Test_FromError_grpcStatus(t *testing.T) {
+	tt := []struct {
+		code     codes.Code
+		exitCode int
+		message  string
+	}{
+		{codes.NotFound, exitcode.NotFound, "not found"},
+		{codes.AlreadyExists, exitcode.AlreadyExists, "already exists"},
+		{codes.InvalidArgument, exitcode.Usage, "invalid request"},
+		{codes.Unauthenticated, exitcode.Unauthenticated, "authentication failed, check --token"},
+		{codes.PermissionDenied, exitcode.Unauthenticated, "permission denied"},
+		{codes.Unavailable, exitcode.Unavailable, "could not reach the flintlock server, check --grpc-address"},
+		{codes.DeadlineExceeded, exitcode.Unavailable, "timed out waiting for the flintlock server"},
+		{codes.Internal, exitcode.ServerError, "flintlock server error"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.code.String(), func(t *testing.T) {
+			g := NewWithT(t)
+
+			err := exitcode.FromError(status.Error(tc.code, "oh no"))
+			g.Expect(err.Code).To(Equal(tc.exitCode))
+			g.Expect(err.Message).To(Equal(tc.message))
+			g.Expect(err.Details).To(Equal([]string{"grpc status: " + tc.code.String(), "server message: oh no"}))
+			g.Expect(err.Error()).To(Equal(tc.message + ": oh no"))
+		})
+	}
+}
+
+func Test_FromError_wrapped(t *testing.T) {
+	g := NewWithT(t)
+
+	err := exitcode.FromError(fmt.Errorf("deleting: %w", status.Error(codes.NotFound, "oh no")))
+	g.Expect(err.Code).To(Equal(exitcode.NotFound))
+
+	err = exitcode.FromError(fmt.Errorf("context: %w", exitcode.New(exitcode.Usage, "required: --%s", "file")))
+	g.Expect(err.Code).To(Equal(exitcode.Usage))
+	g.Expect(err.Error()).To(Equal("required: --file"))
+}
+
+func Test_FromError_other(t *testing.T) {
+	g := NewWithT(t)
+
+	err := exitcode.FromError(errors.New("oh no"))
+	g.Expect(err.Code).To(Equal(exitcode.Failure))
+	g.Expect(err.Message).To(Equal("oh no"))
+}
+
+func Test_Write(t *testing.T) {
+	g := NewWithT(t)
+
+	buf := &bytes.Buffer{}
+
+	code := exitcode.Write(buf, status.Error(codes.Unauthenticated, "unauthenticated"), "")
+	g.Expect(code).To(Equal(exitcode.Unauthenticated))
+	g.Expect(buf.String()).To(Equal("Error: authentication failed, check --token: unauthenticated\n"))
+}
+
+func Test_Write_json(t *testing.T) {
+	g := NewWithT(t)
+
+	buf := &bytes.Buffer{}
+
+	code := exitcode.Write(buf, status.Error(codes.NotFound, "microvm abc not found"), exitcode.JSONFormat)
+	g.Expect(code).To(Equal(exitcode.NotFound))
+
+	out := map[string]interface{}{}
+	g.Expect(json.Unmarshal(buf.Bytes(), &out)).To(Succeed())
+	g.Expect(out).To(Equal(map[string]interface{}{
+		"code":    float64(exitcode.NotFound),
+		"message": "not found",
+		"details": []interface{}{"grpc status: NotFound", "server message: microvm abc not found"},
+	}))
+}
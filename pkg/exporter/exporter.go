@@ -0,0 +1,207 @@
+package exporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/stats"
+)
+
+var (
+	microvmsDesc = prometheus.NewDesc("hammertime_microvms",
+		"Number of microvms.", []string{"host", "namespace", "state"}, nil)
+	vcpusDesc = prometheus.NewDesc("hammertime_vcpus",
+		"Total vcpus committed to microvms.", []string{"host", "namespace"}, nil)
+	memoryDesc = prometheus.NewDesc("hammertime_memory_bytes",
+		"Total memory committed to microvms.", []string{"host", "namespace"}, nil)
+	successDesc = prometheus.NewDesc("hammertime_scrape_success",
+		"Whether the last listing of the host's microvms succeeded.", []string{"host"}, nil)
+	durationDesc = prometheus.NewDesc("hammertime_scrape_duration_seconds",
+		"Time taken by the last listing of the host's microvms.", []string{"host"}, nil)
+)
+
+const bytesPerMb = 1024 * 1024
+
+// Host is a flintlock server to list Microvms from.
+type Host struct {
+	Address string
+	Client  client.FlintlockClient
+}
+
+// result is the outcome of the last listing of a host.
+type result struct {
+	samples  []stats.Sample
+	err      error
+	duration time.Duration
+}
+
+// Exporter lists the Microvms on each host periodically, and exposes the
+// results of the last listing as Prometheus gauges. It is a
+// prometheus.Collector.
+type Exporter struct {
+	hosts   []Host
+	timeout time.Duration
+
+	mu      sync.Mutex
+	results map[string]result
+}
+
+// New returns an Exporter for the hosts. A host which does not answer within
+// timeout (if it is set) is reported as failed. Hosts repeated by address are
+// only listed once. Nothing is exported until the first Scrape.
+func New(hosts []Host, timeout time.Duration) *Exporter {
+	unique := make([]Host, 0, len(hosts))
+	seen := map[string]bool{}
+
+	for _, host := range hosts {
+		if !seen[host.Address] {
+			seen[host.Address] = true
+			unique = append(unique, host)
+		}
+	}
+
+	return &Exporter{hosts: unique, timeout: timeout, results: map[string]result{}}
+}
+
+// Run scrapes the hosts now and then every interval, until ctx is done.
+func (e *Exporter) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		e.Scrape()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scrape lists the Microvms on every host at once. A host which fails has its
+// inventory dropped, rather than exporting stale values, and reports failure.
+func (e *Exporter) Scrape() {
+	results := make([]result, len(e.hosts))
+
+	var wg sync.WaitGroup
+
+	for i, host := range e.hosts {
+		wg.Add(1)
+
+		go func(i int, host Host) {
+			defer wg.Done()
+
+			results[i] = scrapeWithin(host, e.timeout)
+		}(i, host)
+	}
+
+	wg.Wait()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for i, host := range e.hosts {
+		e.results[host.Address] = results[i]
+	}
+}
+
+// scrapeWithin scrapes the host, giving up after timeout so that one hung
+// host cannot hold up every other. The listing is left to finish, or be timed
+// out by the client, in the background.
+func scrapeWithin(host Host, timeout time.Duration) result {
+	if timeout <= 0 {
+		return scrape(host)
+	}
+
+	done := make(chan result, 1)
+
+	go func() {
+		done <- scrape(host)
+	}()
+
+	select {
+	case res := <-done:
+		return res
+	case <-time.After(timeout):
+		return result{err: fmt.Errorf("listing microvms on %s timed out after %s", host.Address, timeout), duration: timeout}
+	}
+}
+
+func scrape(host Host) result {
+	start := time.Now()
+
+	res, err := host.Client.List("", "")
+	if err != nil {
+		return result{err: err, duration: time.Since(start)}
+	}
+
+	samples := make([]stats.Sample, 0, len(res.GetMicrovm()))
+	for _, mvm := range res.GetMicrovm() {
+		samples = append(samples, stats.Sample{Host: host.Address, MicroVM: mvm})
+	}
+
+	return result{samples: samples, duration: time.Since(start)}
+}
+
+// Describe implements prometheus.Collector.
+func (e *Exporter) Describe(ch chan<- *prometheus.Desc) {
+	ch <- microvmsDesc
+	ch <- vcpusDesc
+	ch <- memoryDesc
+	ch <- successDesc
+	ch <- durationDesc
+}
+
+// Collect implements prometheus.Collector.
+func (e *Exporter) Collect(ch chan<- prometheus.Metric) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	samples := []stats.Sample{}
+
+	for _, host := range e.hosts {
+		res, ok := e.results[host.Address]
+		if !ok {
+			continue
+		}
+
+		success := 1.0
+		if res.err != nil {
+			success = 0
+		}
+
+		ch <- prometheus.MustNewConstMetric(successDesc, prometheus.GaugeValue, success, host.Address)
+		ch <- prometheus.MustNewConstMetric(durationDesc, prometheus.GaugeValue, res.duration.Seconds(), host.Address)
+
+		samples = append(samples, res.samples...)
+	}
+
+	for _, group := range stats.Summarise(samples, []string{stats.ByHost, stats.ByNamespace, stats.ByState}) {
+		ch <- prometheus.MustNewConstMetric(microvmsDesc, prometheus.GaugeValue, float64(group.MicroVMs),
+			group.Keys[stats.ByHost], group.Keys[stats.ByNamespace], group.Keys[stats.ByState])
+	}
+
+	for _, group := range stats.Summarise(samples, []string{stats.ByHost, stats.ByNamespace}) {
+		host, namespace := group.Keys[stats.ByHost], group.Keys[stats.ByNamespace]
+
+		ch <- prometheus.MustNewConstMetric(vcpusDesc, prometheus.GaugeValue, float64(group.Vcpus), host, namespace)
+		ch <- prometheus.MustNewConstMetric(memoryDesc, prometheus.GaugeValue,
+			float64(group.MemoryMb*bytesPerMb), host, namespace)
+	}
+}
+
+// Handler returns an http.Handler which serves the exporter's metrics.
+func (e *Exporter) Handler() http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(e)
+
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
@@ -0,0 +1,180 @@
+package exporter_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+
+	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
+	"github.com/warehouse-13/hammertime/pkg/exporter"
+	"github.com/warehouse-13/hammertime/pkg/internal/fixtures"
+)
+
+func Test_Exporter(t *testing.T) {
+	g := NewWithT(t)
+
+	hostA, hostB := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	hostA.ListReturns(fixtures.ListResponse(
+		microvm("ns0", types.MicroVMStatus_CREATED, 2, 1024),
+		microvm("ns0", types.MicroVMStatus_FAILED, 1, 512),
+		microvm("ns1", types.MicroVMStatus_CREATED, 4, 2048),
+	), nil)
+	hostB.ListReturns(nil, errors.New("unavailable"))
+
+	exp := exporter.New([]exporter.Host{{Address: "a:9090", Client: hostA}, {Address: "b:9090", Client: hostB}}, 0)
+
+	g.Expect(testutil.CollectAndCount(exp)).To(BeZero())
+
+	exp.Scrape()
+
+	expected := `
+# HELP hammertime_microvms Number of microvms.
+# TYPE hammertime_microvms gauge
+hammertime_microvms{host="a:9090",namespace="ns0",state="CREATED"} 1
+hammertime_microvms{host="a:9090",namespace="ns0",state="FAILED"} 1
+hammertime_microvms{host="a:9090",namespace="ns1",state="CREATED"} 1
+# HELP hammertime_vcpus Total vcpus committed to microvms.
+# TYPE hammertime_vcpus gauge
+hammertime_vcpus{host="a:9090",namespace="ns0"} 3
+hammertime_vcpus{host="a:9090",namespace="ns1"} 4
+# HELP hammertime_memory_bytes Total memory committed to microvms.
+# TYPE hammertime_memory_bytes gauge
+hammertime_memory_bytes{host="a:9090",namespace="ns0"} 1.610612736e+09
+hammertime_memory_bytes{host="a:9090",namespace="ns1"} 2.147483648e+09
+# HELP hammertime_scrape_success Whether the last listing of the host's microvms succeeded.
+# TYPE hammertime_scrape_success gauge
+hammertime_scrape_success{host="a:9090"} 1
+hammertime_scrape_success{host="b:9090"} 0
+`
+	g.Expect(testutil.CollectAndCompare(exp, strings.NewReader(expected),
+		"hammertime_microvms", "hammertime_vcpus", "hammertime_memory_bytes", "hammertime_scrape_success",
+	)).To(Succeed())
+
+	g.Expect(testutil.CollectAndCount(exp, "hammertime_scrape_duration_seconds")).To(Equal(2))
+}
+
+func Test_Exporter_dropsFailedHost(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.ListReturnsOnCall(0, fixtures.ListResponse(microvm("ns0", types.MicroVMStatus_CREATED, 2, 1024)), nil)
+	mockClient.ListReturnsOnCall(1, nil, errors.New("unavailable"))
+
+	exp := exporter.New([]exporter.Host{{Address: "a:9090", Client: mockClient}}, 0)
+
+	exp.Scrape()
+	g.Expect(testutil.CollectAndCount(exp, "hammertime_microvms")).To(Equal(1))
+
+	exp.Scrape()
+	g.Expect(testutil.CollectAndCount(exp, "hammertime_microvms")).To(BeZero())
+	g.Expect(testutil.CollectAndCount(exp, "hammertime_scrape_success")).To(Equal(1))
+}
+
+func Test_Exporter_hungHost(t *testing.T) {
+	g := NewWithT(t)
+
+	release := make(chan struct{})
+	t.Cleanup(func() { close(release) })
+
+	hostA, hostB := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	hostA.ListStub = func(string, string) (*v1alpha1.ListMicroVMsResponse, error) {
+		<-release
+
+		return fixtures.ListResponse(), nil
+	}
+	hostB.ListReturns(fixtures.ListResponse(microvm("ns0", types.MicroVMStatus_CREATED, 2, 1024)), nil)
+
+	exp := exporter.New([]exporter.Host{{Address: "a:9090", Client: hostA}, {Address: "b:9090", Client: hostB}},
+		10*time.Millisecond)
+
+	done := make(chan struct{})
+
+	go func() {
+		exp.Scrape()
+		close(done)
+	}()
+
+	g.Eventually(done).Should(BeClosed())
+
+	expected := `
+# HELP hammertime_scrape_success Whether the last listing of the host's microvms succeeded.
+# TYPE hammertime_scrape_success gauge
+hammertime_scrape_success{host="a:9090"} 0
+hammertime_scrape_success{host="b:9090"} 1
+`
+	g.Expect(testutil.CollectAndCompare(exp, strings.NewReader(expected), "hammertime_scrape_success")).To(Succeed())
+	g.Expect(testutil.CollectAndCount(exp, "hammertime_microvms")).To(Equal(1))
+}
+
+func Test_Exporter_duplicateHosts(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.ListReturns(fixtures.ListResponse(microvm("ns0", types.MicroVMStatus_CREATED, 2, 1024)), nil)
+
+	host := exporter.Host{Address: "a:9090", Client: mockClient}
+
+	exp := exporter.New([]exporter.Host{host, host}, 0)
+	exp.Scrape()
+
+	g.Expect(mockClient.ListCallCount()).To(Equal(1))
+	g.Expect(testutil.CollectAndCount(exp, "hammertime_scrape_success")).To(Equal(1))
+}
+
+func Test_Exporter_Run(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.ListReturns(fixtures.ListResponse(microvm("ns0", types.MicroVMStatus_CREATED, 2, 1024)), nil)
+
+	exp := exporter.New([]exporter.Host{{Address: "a:9090", Client: mockClient}}, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+
+	go func() {
+		exp.Run(ctx, 10*time.Millisecond)
+		close(done)
+	}()
+
+	g.Eventually(mockClient.ListCallCount).Should(BeNumerically(">=", 2))
+
+	cancel()
+	g.Eventually(done).Should(BeClosed())
+}
+
+func Test_Exporter_Handler(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.ListReturns(fixtures.ListResponse(microvm("ns0", types.MicroVMStatus_CREATED, 2, 1024)), nil)
+
+	exp := exporter.New([]exporter.Host{{Address: "a:9090", Client: mockClient}}, 0)
+	exp.Scrape()
+
+	server := httptest.NewServer(exp.Handler())
+	t.Cleanup(server.Close)
+
+	res, err := http.Get(server.URL)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	defer res.Body.Close()
+
+	body, err := io.ReadAll(res.Body)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(body)).To(ContainSubstring(`hammertime_vcpus{host="a:9090",namespace="ns0"} 2`))
+}
+
+func microvm(namespace string, state types.MicroVMStatus_MicroVMState, vcpu, memory int32) *types.MicroVM {
+	return fixtures.MicroVM(namespace, "mvm", "uid", fixtures.WithState(state), fixtures.WithResources(vcpu, memory))
+}
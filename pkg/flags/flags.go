@@ -1,10 +1,21 @@
 package flags
 
 import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/urfave/cli/v2"
 
 	"github.com/warehouse-13/hammertime/pkg/config"
 	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/logger"
+	"github.com/warehouse-13/hammertime/pkg/preset"
+	"github.com/warehouse-13/hammertime/pkg/stats"
+	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
 // WithFlagsFunc can be used with CLIFlags to build a list of flags for a
@@ -23,6 +34,21 @@ func CLIFlags(options ...WithFlagsFunc) []cli.Flag {
 	return flags
 }
 
+// WithErrorFormatFlag adds the error-format flag to the app.
+func WithErrorFormatFlag() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:    "error-format",
+				Value:   exitcode.TextFormat,
+				EnvVars: []string{"HAMMERTIME_ERROR_FORMAT"},
+				Usage: fmt.Sprintf("format errors are written to stderr in, one of: %s, %s",
+					exitcode.TextFormat, exitcode.JSONFormat),
+			},
+		}
+	}
+}
+
 // WithGRPCAddressFlag adds the flintlock GRPC address flag to the command.
 func WithGRPCAddressFlag() WithFlagsFunc {
 	return func() []cli.Flag {
@@ -31,13 +57,124 @@ func WithGRPCAddressFlag() WithFlagsFunc {
 				Name:    "grpc-address",
 				Value:   defaults.DialTarget,
 				Aliases: []string{"a"},
-				Usage:   "flintlock server address + port",
+				Usage:   "flintlock server address: host:port, unix:///path/to/socket or dns+srv://_service._proto.domain",
+			},
+		}
+	}
+}
+
+// WithProxyFlags adds the proxy and ssh-jump flags to the command.
+func WithProxyFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:    "proxy",
+				EnvVars: []string{"HAMMERTIME_PROXY"},
+				Usage:   "connect through a proxy: socks5://[user:pass@]host:port or http://[user:pass@]host:port",
+			},
+			&cli.StringFlag{
+				Name:  "ssh-jump",
+				Usage: "connect through an ssh tunnel via this host (eg. user@bastion), using your ssh config and agent",
+			},
+		}
+	}
+}
+
+// WithRetryFlags adds the retries and retry-backoff flags to the command.
+func WithRetryFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.IntFlag{
+				Name:  "retries",
+				Value: defaults.Retries,
+				Usage: "times to retry get, list and delete calls which fail with a transient error",
+			},
+			&cli.DurationFlag{
+				Name:  "retry-backoff",
+				Value: defaults.RetryBackoff,
+				Usage: "wait before the first retry, doubled for each one after",
+			},
+		}
+	}
+}
+
+// WithTimeoutFlag adds the timeout flag to the command.
+func WithTimeoutFlag() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "timeout",
+				Value: defaults.PingTimeout,
+				Usage: "time allowed to connect to and check each server",
+			},
+		}
+	}
+}
+
+// WithRefreshFlag adds the refresh flag to the command, defaulting to value.
+func WithRefreshFlag(value time.Duration) WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.DurationFlag{
+				Name:  "refresh",
+				Value: value,
+				Usage: "how often to list the microvms again",
+			},
+		}
+	}
+}
+
+// WithListenFlag adds the listen flag to the command, defaulting to value.
+func WithListenFlag(value string) WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:  "listen",
+				Value: value,
+				Usage: "address to serve http on, eg. :9100 or 127.0.0.1:9100",
+			},
+		}
+	}
+}
+
+// WithGroupByFlag adds the group-by flag to the command.
+func WithGroupByFlag() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "group-by",
+				Value: cli.NewStringSlice(stats.ByHost, stats.ByNamespace),
+				Usage: fmt.Sprintf("total microvms by %s, %s, %s or %s<key> (can be repeated)",
+					stats.ByHost, stats.ByNamespace, stats.ByState, stats.LabelPrefix),
+			},
+		}
+	}
+}
+
+// WithLogFlags adds the verbose and debug flags to the command. `-v` is a
+// shorthand for `--verbose 1`, as an int alias would need a value.
+func WithLogFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.IntFlag{
+				Name: "verbose",
+				Usage: fmt.Sprintf("log to stderr: %d for each call, %d to add payloads",
+					logger.Calls, logger.Payloads),
+			},
+			&cli.BoolFlag{
+				Name:  "v",
+				Usage: fmt.Sprintf("log each call to stderr, the same as --verbose %d", logger.Calls),
+			},
+			&cli.BoolFlag{
+				Name:  "debug",
+				Usage: fmt.Sprintf("log everything to stderr, the same as --verbose %d", logger.Payloads),
 			},
 		}
 	}
 }
 
 // WithNameAndNamespaceFlags adds the name and namespace flags to the command.
+// With defaults, the no-defaults flag is added to opt out of them.
 func WithNameAndNamespaceFlags(withDefaults bool) WithFlagsFunc {
 	nameFlag := &cli.StringFlag{
 		Name:    "name",
@@ -50,15 +187,28 @@ func WithNameAndNamespaceFlags(withDefaults bool) WithFlagsFunc {
 		Usage:   "microvm namespace",
 	}
 
-	if withDefaults {
-		nameFlag.Value = defaults.MvmName
-		namespaceFlag.Value = defaults.MvmNamespace
+	if !withDefaults {
+		return func() []cli.Flag {
+			return []cli.Flag{
+				nameFlag,
+				namespaceFlag,
+			}
+		}
 	}
 
+	nameFlag.Value = defaults.MvmName
+	namespaceFlag.Value = defaults.MvmNamespace
+
 	return func() []cli.Flag {
 		return []cli.Flag{
 			nameFlag,
 			namespaceFlag,
+			&cli.BoolFlag{
+				Name:    "no-defaults",
+				EnvVars: []string{"HAMMERTIME_NO_DEFAULTS"},
+				Usage: fmt.Sprintf("do not default --name and --namespace to %s and %s, they must be given",
+					defaults.MvmName, defaults.MvmNamespace),
+			},
 		}
 	}
 }
@@ -70,20 +220,123 @@ func WithJSONSpecFlag() WithFlagsFunc {
 			&cli.StringFlag{
 				Name:    "file",
 				Aliases: []string{"f"},
-				Usage:   "path to json file containing full flintlock spec. will override other flags",
+				Usage:   "path to json file containing full flintlock spec (may be a template). will override other flags",
+			},
+			&cli.GenericFlag{
+				Name:  "set",
+				Value: &repeatable{},
+				Usage: "key=value to render the --file template with (can be repeated)",
+			},
+			&cli.StringFlag{
+				Name:  "values",
+				Usage: "path to yaml file containing values to render the --file template with",
 			},
 		}
 	}
 }
 
-// WithSSHKeyFlag adds the public-key-path flag to the command.
-func WithSSHKeyFlag() WithFlagsFunc {
+// WithPresetFlag adds the preset flag to the command.
+func WithPresetFlag() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:  "preset",
+				Value: preset.Default,
+				Usage: "name of the preset to build the microvm from (see `hammertime preset list`)",
+			},
+		}
+	}
+}
+
+// WithConfigDirFlag adds the config-dir flag to the command.
+func WithConfigDirFlag() WithFlagsFunc {
 	return func() []cli.Flag {
 		return []cli.Flag{
 			&cli.StringFlag{
+				Name:    "config-dir",
+				EnvVars: []string{preset.ConfigDirEnv},
+				Usage:   "hammertime config directory (default: hammertime under the user config directory)",
+			},
+		}
+	}
+}
+
+// WithSSHKeyFlag adds the public-key-path and ssh-agent flags to the command.
+func WithSSHKeyFlag() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringSliceFlag{
 				Name:    "public-key-path",
 				Aliases: []string{"k"},
-				Usage:   "path to file containing public SSH key to be added to root user",
+				Usage:   "path to file containing public SSH keys (one per line) to be added to root user (can be repeated)",
+			},
+			&cli.BoolFlag{
+				Name:  "ssh-agent",
+				Usage: "add the public keys held by the running ssh-agent (SSH_AUTH_SOCK) to root user",
+			},
+		}
+	}
+}
+
+// WithUserDataFlags adds the user-data-file and user-data-merge flags to the
+// command.
+func WithUserDataFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:  "user-data-file",
+				Usage: "path to file containing cloud-init user-data (#cloud-config or multipart MIME)",
+			},
+			&cli.BoolFlag{
+				Name:  "user-data-merge",
+				Usage: "merge --user-data-file with the generated user-data instead of using it verbatim",
+			},
+		}
+	}
+}
+
+// WithMetadataFlags adds the metadata, cloud-name and availability-zone flags
+// to the command.
+func WithMetadataFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.GenericFlag{
+				Name:  "metadata",
+				Value: &repeatable{},
+				Usage: "key=value pair to add to the instance meta-data (can be repeated)",
+			},
+			&cli.StringFlag{
+				Name:  "cloud-name",
+				Usage: "cloud name to set in the instance meta-data",
+			},
+			&cli.StringFlag{
+				Name:  "availability-zone",
+				Usage: "availability zone to set in the instance meta-data",
+			},
+		}
+	}
+}
+
+// WithNetworkFlags adds the static addressing and network-config flags to the
+// command.
+func WithNetworkFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:  "static-address",
+				Usage: "static address in CIDR notation for the default interface",
+			},
+			&cli.StringFlag{
+				Name:  "gateway",
+				Usage: "gateway for the static address",
+			},
+			&cli.StringSliceFlag{
+				Name:  "nameserver",
+				Usage: "nameserver for the static address (can be repeated)",
+			},
+			&cli.BoolFlag{
+				Name:  "network-config",
+				Usage: "generate a cloud-init network-config from the interfaces instead of leaving it to flintlock",
 			},
 		}
 	}
@@ -116,6 +369,37 @@ func WithStateFlag() WithFlagsFunc {
 	}
 }
 
+// WithShowMetadataFlags adds the show-metadata and reveal flags to the command.
+func WithShowMetadataFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "show-metadata",
+				Usage: "decode the cloud-init metadata of the microvm",
+			},
+			&cli.BoolFlag{
+				Name:  "reveal",
+				Usage: "do not redact secrets in decoded metadata",
+			},
+		}
+	}
+}
+
+// WithOutputFlag adds the output flag to the command. The first of the given
+// formats is the default.
+func WithOutputFlag(formats ...string) WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:    "output",
+				Aliases: []string{"o"},
+				Value:   formats[0],
+				Usage:   fmt.Sprintf("output format, one of: %s", strings.Join(formats, ", ")),
+			},
+		}
+	}
+}
+
 // WithAllFlag adds the boolean all flag to the command.
 func WithAllFlag() WithFlagsFunc {
 	return func() []cli.Flag {
@@ -128,6 +412,63 @@ func WithAllFlag() WithFlagsFunc {
 	}
 }
 
+// WithParallelismFlag adds the parallelism flag to the command.
+func WithParallelismFlag() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.IntFlag{
+				Name:  "parallelism",
+				Value: defaults.DeleteParallelism,
+				Usage: "number of microvms to delete at once",
+			},
+		}
+	}
+}
+
+// WithProtectionLabelFlag adds the protection-label flag to the command.
+func WithProtectionLabelFlag() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:    "protection-label",
+				Value:   defaults.ProtectionLabel,
+				EnvVars: []string{"HAMMERTIME_PROTECTION_LABEL"},
+				Usage:   "label (key=value or key) which protects a microvm from deletion, empty to disable",
+			},
+		}
+	}
+}
+
+// WithDeleteSafetyFlags adds the yes, force and protection-label flags to the
+// command.
+func WithDeleteSafetyFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return append([]cli.Flag{
+			&cli.BoolFlag{
+				Name:    "yes",
+				Aliases: []string{"y"},
+				Usage:   "do not ask for confirmation before deleting with --all",
+			},
+			&cli.BoolFlag{
+				Name:  "force",
+				Usage: "delete microvms even if they carry the protection label",
+			},
+		}, WithProtectionLabelFlag()()...)
+	}
+}
+
+// WithDryRunFlag adds the dry-run flag to the command.
+func WithDryRunFlag() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "print what would be done without calling flintlock",
+			},
+		}
+	}
+}
+
 // WithQuietFlag adds a silent flag to the command.
 func WithQuietFlag() WithFlagsFunc {
 	return func() []cli.Flag {
@@ -141,6 +482,28 @@ func WithQuietFlag() WithFlagsFunc {
 	}
 }
 
+// WithSSHFlags adds the user, identity-file and print flags to the ssh command.
+func WithSSHFlags() WithFlagsFunc {
+	return func() []cli.Flag {
+		return []cli.Flag{
+			&cli.StringFlag{
+				Name:    "user",
+				Aliases: []string{"u"},
+				Value:   defaults.SSHUser,
+				Usage:   "user to connect to the microvm as",
+			},
+			&cli.StringFlag{
+				Name:  "identity-file",
+				Usage: "path to the private key to connect with",
+			},
+			&cli.BoolFlag{
+				Name:  "print",
+				Usage: "print the ssh command instead of running it",
+			},
+		}
+	}
+}
+
 func WithBasicAuthFlag() WithFlagsFunc {
 	return func() []cli.Flag {
 		return []cli.Flag{
@@ -158,20 +521,160 @@ func WithBasicAuthFlag() WithFlagsFunc {
 func ParseFlags(cfg *config.Config) cli.BeforeFunc {
 	return func(ctx *cli.Context) error {
 		cfg.GRPCAddress = ctx.String("grpc-address")
+		if utils.IsSet(cfg.GRPCAddress) {
+			if err := dialler.ValidateAddress(cfg.GRPCAddress); err != nil {
+				return exitcode.New(exitcode.Usage, "--grpc-address: %s", err)
+			}
+		}
+
 		cfg.Token = ctx.String("token")
+		cfg.Retries = ctx.Int("retries")
+		cfg.RetryBackoff = ctx.Duration("retry-backoff")
+		cfg.Timeout = ctx.Duration("timeout")
+		cfg.Refresh = ctx.Duration("refresh")
+		cfg.Listen = ctx.String("listen")
+
+		cfg.GroupBy = ctx.StringSlice("group-by")
+		if err := stats.ValidateGroupBy(cfg.GroupBy); err != nil {
+			return exitcode.New(exitcode.Usage, "--group-by: %s", err)
+		}
+
+		if proxy := ctx.String("proxy"); utils.IsSet(proxy) {
+			proxyURL, err := dialler.ParseProxyURL(proxy)
+			if err != nil {
+				return err
+			}
+
+			cfg.Proxy = proxyURL
+		}
+
+		cfg.SSHJump = ctx.String("ssh-jump")
+		if cfg.Proxy != nil && utils.IsSet(cfg.SSHJump) {
+			return exitcode.New(exitcode.Usage, "--proxy and --ssh-jump cannot be used together")
+		}
+
+		if (cfg.Proxy != nil || utils.IsSet(cfg.SSHJump)) && dialler.IsUnix(cfg.GRPCAddress) {
+			return exitcode.New(exitcode.Usage, "--proxy and --ssh-jump cannot be used with a unix socket address")
+		}
 
 		cfg.MvmName = ctx.String("name")
 		cfg.MvmNamespace = ctx.String("namespace")
 
+		cfg.NoDefaults = ctx.Bool("no-defaults")
+		if cfg.NoDefaults {
+			if !ctx.IsSet("name") {
+				cfg.MvmName = ""
+			}
+
+			if !ctx.IsSet("namespace") {
+				cfg.MvmNamespace = ""
+			}
+		}
+
 		cfg.JSONFile = ctx.String("file")
-		cfg.SSHKeyPath = ctx.String("public-key-path")
+
+		values, err := utils.LoadTemplateValues(ctx.String("values"), repeated(ctx, "set"))
+		if err != nil {
+			return err
+		}
+
+		cfg.TemplateValues = values
+		cfg.Preset = ctx.String("preset")
+		cfg.ConfigDir = ctx.String("config-dir")
+		cfg.SSHKeyPaths = ctx.StringSlice("public-key-path")
+		cfg.SSHAgent = ctx.Bool("ssh-agent")
+		cfg.UserDataFile = ctx.String("user-data-file")
+		cfg.UserDataMerge = ctx.Bool("user-data-merge")
+
+		metadata, err := utils.ParseKeyValues(repeated(ctx, "metadata"))
+		if err != nil {
+			return err
+		}
+
+		cfg.Metadata = metadata
+		cfg.CloudName = ctx.String("cloud-name")
+		cfg.AvailabilityZone = ctx.String("availability-zone")
+
+		cfg.StaticAddress = ctx.String("static-address")
+		cfg.Gateway = ctx.String("gateway")
+		cfg.Nameservers = ctx.StringSlice("nameserver")
+		cfg.NetworkConfig = ctx.Bool("network-config")
 
 		cfg.State = ctx.Bool("state")
+		cfg.ShowMetadata = ctx.Bool("show-metadata")
+		cfg.Reveal = ctx.Bool("reveal")
+		cfg.Output = ctx.String("output")
 		cfg.DeleteAll = ctx.Bool("all")
 		cfg.Silent = ctx.Bool("quiet")
+		cfg.DryRun = ctx.Bool("dry-run")
+		cfg.Parallelism = ctx.Int("parallelism")
+		cfg.Yes = ctx.Bool("yes")
+		cfg.Force = ctx.Bool("force")
+		cfg.ProtectionLabel = ctx.String("protection-label")
 
 		cfg.UUID = ctx.String("id")
 
+		cfg.SSHUser = ctx.String("user")
+		cfg.SSHIdentityFile = ctx.String("identity-file")
+		cfg.SSHPrint = ctx.Bool("print")
+
+		cfg.Args = ctx.Args().Slice()
+
+		cfg.Verbosity = ctx.Int("verbose")
+		if ctx.Bool("v") && cfg.Verbosity < logger.Calls {
+			cfg.Verbosity = logger.Calls
+		}
+
+		if ctx.Bool("debug") && cfg.Verbosity < logger.Payloads {
+			cfg.Verbosity = logger.Payloads
+		}
+
+		cfg.Logger = logger.New(os.Stderr, cfg.Verbosity)
+		cfg.Logger.Info("resolved config",
+			"command", ctx.Command.Name,
+			"grpcAddress", cfg.GRPCAddress,
+			"token", redact(cfg.Token),
+			"name", cfg.MvmName,
+			"namespace", cfg.MvmNamespace,
+			"uid", cfg.UUID,
+			"file", cfg.JSONFile,
+			"retries", cfg.Retries,
+			"retryBackoff", cfg.RetryBackoff.String(),
+			"args", cfg.Args,
+		)
+
 		return nil
 	}
 }
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	return "REDACTED"
+}
+
+// repeatable is a flag value which collects each value given. Unlike a
+// StringSliceFlag it does not split them on commas, which key=value pairs may
+// well contain.
+type repeatable []string
+
+func (r *repeatable) Set(value string) error {
+	*r = append(*r, value)
+
+	return nil
+}
+
+func (r *repeatable) String() string {
+	return strings.Join(*r, " ")
+}
+
+// repeated returns the values given for a repeatable flag.
+func repeated(ctx *cli.Context, name string) []string {
+	if r, ok := ctx.Generic(name).(*repeatable); ok {
+		return *r
+	}
+
+	return nil
+}
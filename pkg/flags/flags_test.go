@@ -0,0 +1,55 @@
+package flags_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/urfave/cli/v2"
+
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/flags"
+)
+
+// parse runs a command with the flags on args and returns the config they
+// parse into.
+func parse(args []string, options ...flags.WithFlagsFunc) (*config.Config, error) {
+	cfg := &config.Config{}
+
+	app := cli.NewApp()
+	app.Commands = []*cli.Command{{
+		Name:   "cmd",
+		Flags:  flags.CLIFlags(options...),
+		Before: flags.ParseFlags(cfg),
+		Action: func(*cli.Context) error { return nil },
+	}}
+
+	return cfg, app.Run(append([]string{"hammertime", "cmd"}, args...))
+}
+
+func Test_ParseFlags_set(t *testing.T) {
+	g := NewWithT(t)
+
+	cfg, err := parse([]string{"--set", "name=foo", "--set", "cmd=echo a,b"}, flags.WithJSONSpecFlag())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.TemplateValues).To(Equal(map[string]interface{}{"name": "foo", "cmd": "echo a,b"}))
+}
+
+func Test_ParseFlags_metadata(t *testing.T) {
+	g := NewWithT(t)
+
+	cfg, err := parse([]string{"--metadata", "role=web", "--metadata", "tags=a,b"}, flags.WithMetadataFlags())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(cfg.Metadata).To(Equal(map[string]string{"role": "web", "tags": "a,b"}))
+}
+
+func Test_ParseFlags_proxyAndSSHJump(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := parse(
+		[]string{"--proxy", "http://proxy:3128", "--ssh-jump", "user@bastion"},
+		flags.WithGRPCAddressFlag(), flags.WithProxyFlags(),
+	)
+	g.Expect(err).To(MatchError("--proxy and --ssh-jump cannot be used together"))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Usage))
+}
@@ -0,0 +1,361 @@
+package gateway
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/resolver"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+const maxBodyBytes = 1 << 20
+
+// Host is a flintlock server to proxy requests to.
+type Host struct {
+	Address string
+	Client  client.FlintlockClient
+}
+
+// Builder builds the spec of a new Microvm from the config, as `create` does.
+type Builder func(cfg *config.Config) (*types.MicroVMSpec, error)
+
+// CreateRequest is the body of a create. The namespace is taken from the path.
+// Fields which are not set keep the values serve was started with.
+type CreateRequest struct {
+	// Name is the name of the new Microvm.
+	Name string `json:"name"`
+	// Host is the address of the server to create the Microvm on. The first
+	// server is used if it is not set.
+	Host string `json:"host,omitempty"`
+	// Preset is the preset to build the Microvm from.
+	Preset string `json:"preset,omitempty"`
+	// Metadata are extra instance meta-data keys.
+	Metadata map[string]string `json:"metadata,omitempty"`
+	// CloudName is the cloud-name instance meta-data.
+	CloudName string `json:"cloud_name,omitempty"`
+	// AvailabilityZone is the availability-zone instance meta-data.
+	AvailabilityZone string `json:"availability_zone,omitempty"`
+	// StaticAddress is the CIDR address of the first interface.
+	StaticAddress string `json:"static_address,omitempty"`
+	// Gateway is the gateway of the static address.
+	Gateway string `json:"gateway,omitempty"`
+	// Nameservers are the nameservers of the static address.
+	Nameservers []string `json:"nameservers,omitempty"`
+	// NetworkConfig generates the cloud-init network-config.
+	NetworkConfig bool `json:"network_config,omitempty"`
+}
+
+// Server is an http.Handler serving a small REST API in front of the
+// flintlock servers:
+//
+//	GET    /namespaces/{ns}/microvms   list, optionally ?name=
+//	POST   /namespaces/{ns}/microvms   create from a CreateRequest
+//	GET    /microvms/{uid}             get, by uid or unique uid prefix
+//	DELETE /microvms/{uid}             delete, ?force=true for protected ones
+//
+// Responses have the same JSON shapes as the CLI's output, and errors the
+// shape of `--error-format json`.
+type Server struct {
+	hosts []Host
+	cfg   *config.Config
+	build Builder
+}
+
+// New returns a Server for the hosts. cfg holds the defaults for creates, the
+// protection label and the token requests must carry, if any.
+func New(hosts []Host, cfg *config.Config, build Builder) *Server {
+	return &Server{hosts: hosts, cfg: cfg, build: build}
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="hammertime"`)
+		writeError(w, http.StatusUnauthorized, exitcode.New(exitcode.Unauthenticated, "missing or invalid token"))
+
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	switch {
+	case len(parts) == 3 && parts[0] == "namespaces" && parts[2] == "microvms" && parts[1] != "":
+		switch r.Method {
+		case http.MethodGet:
+			s.list(w, r, parts[1])
+		case http.MethodPost:
+			s.create(w, r, parts[1])
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodPost)
+		}
+	case len(parts) == 2 && parts[0] == "microvms" && parts[1] != "":
+		switch r.Method {
+		case http.MethodGet:
+			s.get(w, parts[1])
+		case http.MethodDelete:
+			s.delete(w, r, parts[1])
+		default:
+			methodNotAllowed(w, http.MethodGet, http.MethodDelete)
+		}
+	default:
+		writeError(w, http.StatusNotFound, exitcode.New(exitcode.NotFound, "no route for %s", r.URL.Path))
+	}
+}
+
+// authorized checks the request carries the token flintlock is called with, in
+// the same basic auth form, if there is one.
+func (s *Server) authorized(r *http.Request) bool {
+	if !utils.IsSet(s.cfg.Token) {
+		return true
+	}
+
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte(s.cfg.Token))
+
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) == 1
+}
+
+func (s *Server) list(w http.ResponseWriter, r *http.Request, namespace string) {
+	var (
+		lists = make([]*v1alpha1.ListMicroVMsResponse, len(s.hosts))
+		errs  = make([]error, len(s.hosts))
+		wg    sync.WaitGroup
+	)
+
+	name := r.URL.Query().Get("name")
+
+	for i, host := range s.hosts {
+		wg.Add(1)
+
+		go func(i int, host Host) {
+			defer wg.Done()
+
+			lists[i], errs[i] = host.Client.List(name, namespace)
+		}(i, host)
+	}
+
+	wg.Wait()
+
+	res := &v1alpha1.ListMicroVMsResponse{Microvm: []*types.MicroVM{}}
+
+	for i, host := range s.hosts {
+		if errs[i] != nil {
+			writeError(w, 0, fmt.Errorf("listing microvms on %s: %w", host.Address, errs[i]))
+
+			return
+		}
+
+		res.Microvm = append(res.Microvm, lists[i].GetMicrovm()...)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) create(w http.ResponseWriter, r *http.Request, namespace string) {
+	req := CreateRequest{}
+
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&req); err != nil {
+		writeError(w, 0, exitcode.New(exitcode.Usage, "invalid request body: %s", err))
+
+		return
+	}
+
+	host, err := s.createHost(req.Host)
+	if err != nil {
+		writeError(w, 0, err)
+
+		return
+	}
+
+	cfg, err := s.createConfig(namespace, req)
+	if err != nil {
+		writeError(w, 0, err)
+
+		return
+	}
+
+	spec, err := s.build(cfg)
+	if err != nil {
+		writeError(w, 0, exitcode.New(exitcode.Usage, "%s", err))
+
+		return
+	}
+
+	res, err := host.Client.Create(spec)
+	if err != nil {
+		writeError(w, 0, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, res)
+}
+
+func (s *Server) createHost(address string) (Host, error) {
+	if !utils.IsSet(address) {
+		return s.hosts[0], nil
+	}
+
+	for _, host := range s.hosts {
+		if host.Address == address {
+			return host, nil
+		}
+	}
+
+	return Host{}, exitcode.New(exitcode.Usage, "unknown host %q", address)
+}
+
+// createConfig returns a copy of the server's config with the request applied.
+func (s *Server) createConfig(namespace string, req CreateRequest) (*config.Config, error) {
+	if !utils.IsSet(req.Name) || strings.Contains(req.Name, "/") {
+		return nil, exitcode.New(exitcode.Usage, "required: name")
+	}
+
+	cfg := *s.cfg
+	cfg.MvmName = req.Name
+	cfg.MvmNamespace = namespace
+	cfg.CloudName = pick(req.CloudName, cfg.CloudName)
+	cfg.AvailabilityZone = pick(req.AvailabilityZone, cfg.AvailabilityZone)
+	cfg.Preset = pick(req.Preset, cfg.Preset)
+	cfg.StaticAddress = pick(req.StaticAddress, cfg.StaticAddress)
+	cfg.Gateway = pick(req.Gateway, cfg.Gateway)
+	cfg.NetworkConfig = req.NetworkConfig || cfg.NetworkConfig
+
+	if len(req.Nameservers) > 0 {
+		cfg.Nameservers = req.Nameservers
+	}
+
+	if len(req.Metadata) > 0 {
+		cfg.Metadata = req.Metadata
+	}
+
+	return &cfg, nil
+}
+
+func (s *Server) get(w http.ResponseWriter, uid string) {
+	_, mvm, err := s.find(uid)
+	if err != nil {
+		writeError(w, 0, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, mvm)
+}
+
+func (s *Server) delete(w http.ResponseWriter, r *http.Request, uid string) {
+	host, mvm, err := s.find(uid)
+	if err != nil {
+		writeError(w, 0, err)
+
+		return
+	}
+
+	force := r.URL.Query().Get("force") == "true"
+	if !force && utils.IsSet(s.cfg.ProtectionLabel) && utils.HasLabel(mvm.Spec.Labels, s.cfg.ProtectionLabel) {
+		writeError(w, 0, exitcode.New(exitcode.Protected,
+			"refusing to delete protected MicroVM %s (labelled %s), re-send with ?force=true to delete it",
+			resolver.Describe(mvm), s.cfg.ProtectionLabel))
+
+		return
+	}
+
+	res, err := host.Client.Delete(mvm.Spec.GetUid())
+	if err != nil {
+		writeError(w, 0, err)
+
+		return
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+// find looks for the uid, or a unique prefix of one, on each host in turn.
+func (s *Server) find(uid string) (Host, *types.MicroVM, error) {
+	for _, host := range s.hosts {
+		mvm, err := resolver.New(host.Client).One(resolver.Ref{UID: uid})
+		if err == nil {
+			return host, mvm, nil
+		}
+
+		if exitcode.FromError(err).Code != exitcode.NotFound {
+			return Host{}, nil, err
+		}
+	}
+
+	return Host{}, nil, exitcode.New(exitcode.NotFound, "MicroVM %s not found", uid)
+}
+
+func pick(value, fallback string) string {
+	if utils.IsSet(value) {
+		return value
+	}
+
+	return fallback
+}
+
+func methodNotAllowed(w http.ResponseWriter, methods ...string) {
+	w.Header().Set("Allow", strings.Join(methods, ", "))
+	writeError(w, http.StatusMethodNotAllowed, exitcode.New(exitcode.Usage, "method not allowed"))
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	out, err := json.Marshal(body)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(out, '\n')) //nolint: errcheck // the client has gone
+}
+
+// writeError writes err as the JSON an `--error-format json` error would be.
+// A status of 0 is derived from the error's exit code.
+func writeError(w http.ResponseWriter, status int, err error) {
+	exitErr := exitcode.FromError(err)
+
+	if status == 0 {
+		status = httpStatus(exitErr.Code)
+	}
+
+	out, _ := json.Marshal(exitErr) //nolint: errchkjson // an Error always marshals
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(out, '\n')) //nolint: errcheck // the client has gone
+}
+
+func httpStatus(code int) int {
+	switch code {
+	case exitcode.Usage:
+		return http.StatusBadRequest
+	case exitcode.NotFound:
+		return http.StatusNotFound
+	case exitcode.AlreadyExists, exitcode.Protected:
+		return http.StatusConflict
+	case exitcode.Unauthenticated:
+		return http.StatusUnauthorized
+	case exitcode.Unavailable:
+		return http.StatusServiceUnavailable
+	case exitcode.ServerError:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,313 @@
+package gateway_test
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
+	"github.com/warehouse-13/hammertime/pkg/config"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/gateway"
+	"github.com/warehouse-13/hammertime/pkg/internal/fixtures"
+)
+
+const uid = "1234567890"
+
+func Test_List(t *testing.T) {
+	g := NewWithT(t)
+
+	hostA, hostB := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	hostA.ListReturns(fixtures.ListResponse(fixtures.MicroVM("ns", "a", "uid-a")), nil)
+	hostB.ListReturns(fixtures.ListResponse(fixtures.MicroVM("ns", "b", "uid-b")), nil)
+
+	server := gateway.New(hosts(hostA, hostB), &config.Config{}, nil)
+
+	rec := serve(server, http.MethodGet, "/namespaces/ns/microvms?name=a", "")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+
+	res := &v1alpha1.ListMicroVMsResponse{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), res)).To(Succeed())
+	g.Expect(res.Microvm).To(HaveLen(2))
+	g.Expect(res.Microvm[0].Spec.Id).To(Equal("a"))
+	g.Expect(res.Microvm[1].Spec.Id).To(Equal("b"))
+
+	name, namespace := hostA.ListArgsForCall(0)
+	g.Expect(name).To(Equal("a"))
+	g.Expect(namespace).To(Equal("ns"))
+}
+
+func Test_List_hostFails(t *testing.T) {
+	g := NewWithT(t)
+
+	hostA, hostB := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	hostB.ListReturns(nil, errors.New("boom"))
+
+	server := gateway.New(hosts(hostA, hostB), &config.Config{}, nil)
+
+	rec := serve(server, http.MethodGet, "/namespaces/ns/microvms", "")
+	g.Expect(rec.Code).To(Equal(http.StatusInternalServerError))
+	g.Expect(decodeError(g, rec).Message).To(Equal("listing microvms on b:9090: boom"))
+}
+
+func Test_Create(t *testing.T) {
+	g := NewWithT(t)
+
+	hostA, hostB := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	hostB.CreateReturns(fixtures.CreateResponse(fixtures.MicroVM("ns", "foo", uid)), nil)
+
+	var built *config.Config
+
+	build := func(cfg *config.Config) (*types.MicroVMSpec, error) {
+		built = cfg
+
+		return &types.MicroVMSpec{Id: cfg.MvmName, Namespace: cfg.MvmNamespace}, nil
+	}
+
+	cfg := &config.Config{Preset: "default", CloudName: "cloud"}
+	server := gateway.New(hosts(hostA, hostB), cfg, build)
+
+	rec := serve(server, http.MethodPost, "/namespaces/ns/microvms",
+		`{"name": "foo", "host": "b:9090", "preset": "big"}`)
+	g.Expect(rec.Code).To(Equal(http.StatusCreated))
+
+	res := &v1alpha1.CreateMicroVMResponse{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), res)).To(Succeed())
+	g.Expect(res.Microvm.Spec.GetUid()).To(Equal(uid))
+
+	g.Expect(built.MvmName).To(Equal("foo"))
+	g.Expect(built.MvmNamespace).To(Equal("ns"))
+	g.Expect(built.Preset).To(Equal("big"))
+	g.Expect(built.CloudName).To(Equal("cloud"))
+	g.Expect(cfg.Preset).To(Equal("default"))
+
+	g.Expect(hostA.CreateCallCount()).To(BeZero())
+	g.Expect(hostB.CreateArgsForCall(0).Id).To(Equal("foo"))
+}
+
+func Test_Create_invalid(t *testing.T) {
+	tt := []struct {
+		name string
+		body string
+		err  string
+	}{
+		{
+			name: "without a name",
+			body: `{}`,
+			err:  "required: name",
+		},
+		{
+			name: "with an unknown field",
+			body: `{"name": "foo", "labels": {}}`,
+			err:  `invalid request body: json: unknown field "labels"`,
+		},
+		{
+			name: "on an unknown host",
+			body: `{"name": "foo", "host": "c:9090"}`,
+			err:  `unknown host "c:9090"`,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mockClient := new(fakeclient.FakeFlintlockClient)
+			server := gateway.New(hosts(mockClient), &config.Config{}, nil)
+
+			rec := serve(server, http.MethodPost, "/namespaces/ns/microvms", tc.body)
+			g.Expect(rec.Code).To(Equal(http.StatusBadRequest))
+			g.Expect(decodeError(g, rec).Message).To(Equal(tc.err))
+			g.Expect(mockClient.CreateCallCount()).To(BeZero())
+		})
+	}
+}
+
+func Test_Get(t *testing.T) {
+	g := NewWithT(t)
+
+	hostA, hostB := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	hostA.ListReturns(fixtures.ListResponse(), nil)
+	hostB.ListReturns(fixtures.ListResponse(fixtures.MicroVM("ns", "foo", uid)), nil)
+
+	server := gateway.New(hosts(hostA, hostB), &config.Config{}, nil)
+
+	rec := serve(server, http.MethodGet, "/microvms/1234", "")
+	g.Expect(rec.Code).To(Equal(http.StatusOK))
+
+	res := &types.MicroVM{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), res)).To(Succeed())
+	g.Expect(res.Spec.GetUid()).To(Equal(uid))
+}
+
+func Test_Get_notFound(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.ListReturns(fixtures.ListResponse(), nil)
+
+	server := gateway.New(hosts(mockClient), &config.Config{}, nil)
+
+	rec := serve(server, http.MethodGet, "/microvms/"+uid, "")
+	g.Expect(rec.Code).To(Equal(http.StatusNotFound))
+	g.Expect(decodeError(g, rec).Code).To(Equal(exitcode.NotFound))
+}
+
+func Test_Delete(t *testing.T) {
+	tt := []struct {
+		name    string
+		query   string
+		labels  map[string]string
+		status  int
+		deletes int
+	}{
+		{
+			name:    "unprotected",
+			status:  http.StatusOK,
+			deletes: 1,
+		},
+		{
+			name:   "protected",
+			labels: map[string]string{"protected": "true"},
+			status: http.StatusConflict,
+		},
+		{
+			name:    "protected with ?force=true",
+			query:   "?force=true",
+			labels:  map[string]string{"protected": "true"},
+			status:  http.StatusOK,
+			deletes: 1,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mvm := fixtures.MicroVM("ns", "foo", uid)
+			mvm.Spec.Labels = tc.labels
+
+			mockClient := new(fakeclient.FakeFlintlockClient)
+			mockClient.GetReturns(fixtures.GetResponse(mvm), nil)
+			mockClient.DeleteReturns(&emptypb.Empty{}, nil)
+
+			cfg := &config.Config{ProtectionLabel: "protected"}
+			server := gateway.New(hosts(mockClient), cfg, nil)
+
+			rec := serve(server, http.MethodDelete, "/microvms/"+uid+tc.query, "")
+			g.Expect(rec.Code).To(Equal(tc.status))
+			g.Expect(mockClient.DeleteCallCount()).To(Equal(tc.deletes))
+
+			if tc.deletes > 0 {
+				g.Expect(mockClient.DeleteArgsForCall(0)).To(Equal(uid))
+			}
+		})
+	}
+}
+
+func Test_Auth(t *testing.T) {
+	tt := []struct {
+		name   string
+		header string
+		status int
+	}{
+		{
+			name:   "without a token",
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "with the wrong token",
+			header: "Basic " + base64.StdEncoding.EncodeToString([]byte("wrong")),
+			status: http.StatusUnauthorized,
+		},
+		{
+			name:   "with the token",
+			header: "Basic " + base64.StdEncoding.EncodeToString([]byte("secret")),
+			status: http.StatusOK,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			mockClient := new(fakeclient.FakeFlintlockClient)
+			mockClient.ListReturns(fixtures.ListResponse(), nil)
+
+			cfg := &config.Config{Token: "secret"}
+			server := gateway.New(hosts(mockClient), cfg, nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/namespaces/ns/microvms", nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, req)
+
+			g.Expect(rec.Code).To(Equal(tc.status))
+		})
+	}
+}
+
+func Test_Routes(t *testing.T) {
+	tt := []struct {
+		method string
+		path   string
+		status int
+		allow  string
+	}{
+		{method: http.MethodGet, path: "/", status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/namespaces//microvms", status: http.StatusNotFound},
+		{method: http.MethodGet, path: "/microvms", status: http.StatusNotFound},
+		{method: http.MethodPut, path: "/namespaces/ns/microvms", status: http.StatusMethodNotAllowed, allow: "GET, POST"},
+		{method: http.MethodPost, path: "/microvms/" + uid, status: http.StatusMethodNotAllowed, allow: "GET, DELETE"},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.method+" "+tc.path, func(t *testing.T) {
+			g := NewWithT(t)
+
+			server := gateway.New(hosts(new(fakeclient.FakeFlintlockClient)), &config.Config{}, nil)
+
+			rec := serve(server, tc.method, tc.path, "")
+			g.Expect(rec.Code).To(Equal(tc.status))
+			g.Expect(rec.Header().Get("Allow")).To(Equal(tc.allow))
+			g.Expect(rec.Header().Get("Content-Type")).To(Equal("application/json"))
+		})
+	}
+}
+
+func serve(server *gateway.Server, method, path, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	server.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+
+	return rec
+}
+
+func decodeError(g *WithT, rec *httptest.ResponseRecorder) *exitcode.Error {
+	out := &exitcode.Error{}
+	g.Expect(json.Unmarshal(rec.Body.Bytes(), out)).To(Succeed())
+
+	return out
+}
+
+func hosts(clients ...*fakeclient.FakeFlintlockClient) []gateway.Host {
+	addresses := []string{"a:9090", "b:9090"}
+	hosts := make([]gateway.Host, 0, len(clients))
+
+	for i, c := range clients {
+		hosts = append(hosts, gateway.Host{Address: addresses[i], Client: c})
+	}
+
+	return hosts
+}
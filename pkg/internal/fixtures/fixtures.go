@@ -0,0 +1,91 @@
+// Package fixtures builds the Microvms and flintlock responses which tests
+// feed to fake clients.
+package fixtures
+
+import (
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"k8s.io/utils/pointer"
+)
+
+// Option sets a field of a Microvm built by MicroVM.
+type Option func(*types.MicroVM)
+
+// MicroVM returns a Microvm with the namespace, name and uid, and whatever the
+// options set.
+func MicroVM(namespace, name, uid string, opts ...Option) *types.MicroVM {
+	mvm := &types.MicroVM{
+		Spec: &types.MicroVMSpec{
+			Id:        name,
+			Namespace: namespace,
+			Uid:       pointer.String(uid),
+		},
+	}
+
+	for _, opt := range opts {
+		opt(mvm)
+	}
+
+	return mvm
+}
+
+// WithState sets the Microvm's status.
+func WithState(state types.MicroVMStatus_MicroVMState) Option {
+	return func(mvm *types.MicroVM) {
+		mvm.Status = &types.MicroVMStatus{State: state}
+	}
+}
+
+// WithResources sets the Microvm's vcpus and memory.
+func WithResources(vcpu, memoryInMb int32) Option {
+	return func(mvm *types.MicroVM) {
+		mvm.Spec.Vcpu = vcpu
+		mvm.Spec.MemoryInMb = memoryInMb
+	}
+}
+
+// WithLabels sets the Microvm's labels.
+func WithLabels(labels map[string]string) Option {
+	return func(mvm *types.MicroVM) {
+		mvm.Spec.Labels = labels
+	}
+}
+
+// WithMetadata sets the Microvm's metadata.
+func WithMetadata(metadata map[string]string) Option {
+	return func(mvm *types.MicroVM) {
+		mvm.Spec.Metadata = metadata
+	}
+}
+
+// WithImages sets the Microvm's kernel, root volume and any additional volumes
+// to the container images.
+func WithImages(kernel, root string, additional ...string) Option {
+	return func(mvm *types.MicroVM) {
+		mvm.Spec.Kernel = &types.Kernel{Image: kernel}
+		mvm.Spec.RootVolume = volume(root)
+
+		for _, image := range additional {
+			mvm.Spec.AdditionalVolumes = append(mvm.Spec.AdditionalVolumes, volume(image))
+		}
+	}
+}
+
+// ListResponse returns a List response holding the Microvms.
+func ListResponse(mvms ...*types.MicroVM) *v1alpha1.ListMicroVMsResponse {
+	return &v1alpha1.ListMicroVMsResponse{Microvm: mvms}
+}
+
+// GetResponse returns a Get response holding the Microvm.
+func GetResponse(mvm *types.MicroVM) *v1alpha1.GetMicroVMResponse {
+	return &v1alpha1.GetMicroVMResponse{Microvm: mvm}
+}
+
+// CreateResponse returns a Create response holding the Microvm.
+func CreateResponse(mvm *types.MicroVM) *v1alpha1.CreateMicroVMResponse {
+	return &v1alpha1.CreateMicroVMResponse{Microvm: mvm}
+}
+
+func volume(image string) *types.Volume {
+	return &types.Volume{Source: &types.VolumeSource{ContainerSource: pointer.String(image)}}
+}
@@ -0,0 +1,36 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+)
+
+const (
+	// Calls is the verbosity at which the resolved config, and each call with
+	// its latency and status, are logged.
+	Calls = 1
+	// Payloads is the verbosity at which request and response payloads are
+	// logged as well. --debug sets this.
+	Payloads = 2
+)
+
+// New returns a structured logger which writes `key=value` lines to w. With a
+// verbosity of 0 or less nothing is logged.
+//
+// Messages logged with V(0) are shown from verbosity Calls, and messages
+// logged with V(1) from verbosity Payloads.
+func New(w io.Writer, verbosity int) logr.Logger {
+	if verbosity < Calls {
+		return logr.Discard()
+	}
+
+	return funcr.New(func(prefix, args string) {
+		fmt.Fprintf(w, "time=%q %s\n", time.Now().Format(time.RFC3339Nano), args)
+	}, funcr.Options{
+		Verbosity: verbosity - Calls,
+	})
+}
@@ -0,0 +1,32 @@
+package logger_test
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/logger"
+)
+
+func Test_New(t *testing.T) {
+	g := NewWithT(t)
+
+	buf := &bytes.Buffer{}
+
+	log := logger.New(buf, logger.Calls)
+	log.Info("call", "code", "OK")
+	log.V(1).Info("response", "payload", "{}")
+
+	g.Expect(buf.String()).To(MatchRegexp(`^time="[^"]+" "level"=0 "msg"="call" "code"="OK"\n$`))
+}
+
+func Test_New_off(t *testing.T) {
+	g := NewWithT(t)
+
+	buf := &bytes.Buffer{}
+
+	logger.New(buf, 0).Info("call")
+
+	g.Expect(buf.String()).To(BeEmpty())
+}
@@ -0,0 +1,69 @@
+package microvm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	metaDataKey = "meta-data"
+	userDataKey = "user-data"
+
+	instanceIDKey    = "instance_id"
+	localHostnameKey = "local_hostname"
+)
+
+// Clone returns a copy of the spec which a new Microvm called name can be
+// created from. The uid is cleared, and the instance id and hostname set in the
+// meta-data and user-data are changed to the new name. Everything else,
+// including the namespace, is kept.
+func Clone(spec *types.MicroVMSpec, name string) (*types.MicroVMSpec, error) {
+	clone, _ := proto.Clone(spec).(*types.MicroVMSpec)
+	clone.Id = name
+	clone.Uid = nil
+
+	if value, ok := clone.Metadata[metaDataKey]; ok {
+		metaData, err := renameMetadata(value, name, clone.Namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		clone.Metadata[metaDataKey] = metaData
+	}
+
+	if value, ok := clone.Metadata[userDataKey]; ok {
+		if data, err := base64.StdEncoding.DecodeString(value); err == nil {
+			hostname := regexp.MustCompile(`(?m)^hostname: ` + regexp.QuoteMeta(spec.Id) + `[ \t]*$`)
+			renamed := hostname.ReplaceAll(data, []byte("hostname: "+name))
+
+			clone.Metadata[userDataKey] = base64.StdEncoding.EncodeToString(renamed)
+		}
+	}
+
+	return clone, nil
+}
+
+// renameMetadata rebuilds the meta-data for the new name, keeping any other
+// keys it had.
+func renameMetadata(value, name, ns string) (string, error) {
+	_, doc := decodeValue(value, true)
+	if doc == nil {
+		return "", fmt.Errorf("unable to clone %s: not a YAML document", metaDataKey)
+	}
+
+	extra := map[string]string{}
+
+	for key, val := range doc {
+		if key == instanceIDKey || key == localHostnameKey {
+			continue
+		}
+
+		extra[key] = fmt.Sprint(val)
+	}
+
+	return CreateMetadata(name, ns, WithMetadata(extra))
+}
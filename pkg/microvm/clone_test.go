@@ -0,0 +1,51 @@
+package microvm_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"k8s.io/utils/pointer"
+
+	"github.com/warehouse-13/hammertime/pkg/microvm"
+)
+
+func Test_Clone(t *testing.T) {
+	g := NewWithT(t)
+
+	metaData, err := microvm.CreateMetadata("foo", "bar", microvm.WithCloudName("lab"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	userData, err := microvm.CreateUserData("foo", nil)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	spec := &types.MicroVMSpec{
+		Id:        "foo",
+		Namespace: "bar",
+		Uid:       pointer.String("abc"),
+		Vcpu:      2,
+		Metadata: map[string]string{
+			"meta-data": metaData,
+			"user-data": userData,
+		},
+	}
+
+	clone, err := microvm.Clone(spec, "baz")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(clone.Id).To(Equal("baz"))
+	g.Expect(clone.Namespace).To(Equal("bar"))
+	g.Expect(clone.Uid).To(BeNil())
+	g.Expect(clone.Vcpu).To(Equal(int32(2)))
+
+	decoded := microvm.DecodeMetadata(clone.Metadata, true)
+	g.Expect(decoded["meta-data"]).To(HaveKeyWithValue("instance_id", "bar/baz"))
+	g.Expect(decoded["meta-data"]).To(HaveKeyWithValue("local_hostname", "baz"))
+	g.Expect(decoded["meta-data"]).To(HaveKeyWithValue("cloud_name", "lab"))
+	g.Expect(decoded["user-data"]).To(HaveKeyWithValue("hostname", "baz"))
+
+	// The original is untouched
+	g.Expect(spec.Id).To(Equal("foo"))
+	g.Expect(*spec.Uid).To(Equal("abc"))
+	g.Expect(spec.Metadata["meta-data"]).To(Equal(metaData))
+}
@@ -3,7 +3,6 @@ package microvm
 import (
 	"encoding/base64"
 	"fmt"
-	"os"
 
 	"github.com/weaveworks-liquidmetal/flintlock/client/cloudinit/instance"
 	"github.com/weaveworks-liquidmetal/flintlock/client/cloudinit/userdata"
@@ -12,20 +11,16 @@ import (
 	"github.com/warehouse-13/hammertime/pkg/utils"
 )
 
-func CreateUserData(name, sshPath string) (string, error) {
+// CreateUserData builds base64 encoded cloud-config user-data setting the
+// hostname and authorising the given SSH keys for the root user. Keys can be
+// loaded with LoadSSHKeys.
+func CreateUserData(name string, sshKeys []string) (string, error) {
 	defaultUser := userdata.User{
 		Name: "root",
 	}
 
-	if utils.IsSet(sshPath) {
-		sshKey, err := getKeyFromPath(sshPath)
-		if err != nil {
-			return "", err
-		}
-
-		defaultUser.SSHAuthorizedKeys = []string{
-			sshKey,
-		}
+	if len(sshKeys) > 0 {
+		defaultUser.SSHAuthorizedKeys = sshKeys
 	}
 
 	// TODO: remove the boot command temporary fix after image-builder #6
@@ -50,13 +45,53 @@ func CreateUserData(name, sshPath string) (string, error) {
 	return base64.StdEncoding.EncodeToString(dataWithHeader), nil
 }
 
-func CreateMetadata(name, ns string) (string, error) {
+const (
+	cloudNameKey        = "cloud_name"
+	availabilityZoneKey = "availability_zone"
+)
+
+// MetadataOption configures the instance metadata built by CreateMetadata.
+type MetadataOption func(instance.Metadata)
+
+// WithCloudName sets the cloud name in the instance metadata.
+func WithCloudName(name string) MetadataOption {
+	return withKeyValue(cloudNameKey, name)
+}
+
+// WithAvailabilityZone sets the availability zone in the instance metadata.
+func WithAvailabilityZone(zone string) MetadataOption {
+	return withKeyValue(availabilityZoneKey, zone)
+}
+
+// WithMetadata adds arbitrary keys to the instance metadata. These will
+// override any default keys of the same name.
+func WithMetadata(data map[string]string) MetadataOption {
+	return func(metadata instance.Metadata) {
+		for k, v := range data {
+			metadata[k] = v
+		}
+	}
+}
+
+func withKeyValue(key, value string) MetadataOption {
+	return func(metadata instance.Metadata) {
+		if utils.IsSet(value) {
+			metadata[key] = value
+		}
+	}
+}
+
+func CreateMetadata(name, ns string, opts ...MetadataOption) (string, error) {
 	metadata := instance.New(
 		instance.WithInstanceID(fmt.Sprintf("%s/%s", ns, name)),
 		instance.WithLocalHostname(name),
 		instance.WithPlatform("liquid_metal"),
 	)
 
+	for _, opt := range opts {
+		opt(metadata)
+	}
+
 	userMeta, err := yaml.Marshal(metadata)
 	if err != nil {
 		return "", fmt.Errorf("unable to marshal metadata: %w", err)
@@ -64,12 +99,3 @@ func CreateMetadata(name, ns string) (string, error) {
 
 	return base64.StdEncoding.EncodeToString(userMeta), nil
 }
-
-func getKeyFromPath(path string) (string, error) {
-	key, err := os.ReadFile(path)
-	if err != nil {
-		return "", err
-	}
-
-	return string(key), nil
-}
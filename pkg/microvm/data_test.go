@@ -3,9 +3,6 @@ package microvm_test
 import (
 	"encoding/base64"
 	"fmt"
-	"io/fs"
-	"io/ioutil"
-	"os"
 	"testing"
 
 	. "github.com/onsi/gomega"
@@ -17,12 +14,9 @@ import (
 func Test_CreateUserData(t *testing.T) {
 	g := NewWithT(t)
 
-	var (
-		testName = "foo"
-		testPath = ""
-	)
+	var testName = "foo"
 
-	out, err := microvm.CreateUserData(testName, testPath)
+	out, err := microvm.CreateUserData(testName, nil)
 	g.Expect(err).NotTo(HaveOccurred())
 
 	dat, err := base64.StdEncoding.DecodeString(out)
@@ -36,25 +30,15 @@ func Test_CreateUserData(t *testing.T) {
 	g.Expect(generated.Users[0].SSHAuthorizedKeys).To(HaveLen(0))
 }
 
-func Test_CreateUserData_withSSHKey(t *testing.T) {
+func Test_CreateUserData_withSSHKeys(t *testing.T) {
 	g := NewWithT(t)
 
 	var (
 		testName = "foo"
-		testPath = "userdata_test"
-		testKey  = "this is a key"
+		testKeys = []string{"ssh-ed25519 AAAA one", "ssh-ed25519 AAAA two"}
 	)
 
-	tempFile, err := ioutil.TempFile("", testPath)
-	g.Expect(err).NotTo(HaveOccurred())
-
-	g.Expect(ioutil.WriteFile(tempFile.Name(), []byte(testKey), 0755)).To(Succeed())
-
-	t.Cleanup(func() {
-		g.Expect(os.RemoveAll(tempFile.Name())).To(Succeed())
-	})
-
-	out, err := microvm.CreateUserData(testName, tempFile.Name())
+	out, err := microvm.CreateUserData(testName, testKeys)
 	g.Expect(err).NotTo(HaveOccurred())
 
 	dat, err := base64.StdEncoding.DecodeString(out)
@@ -65,24 +49,31 @@ func Test_CreateUserData_withSSHKey(t *testing.T) {
 	g.Expect(generated.HostName).To(Equal(testName))
 	g.Expect(generated.Users).To(HaveLen(1))
 	g.Expect(generated.Users[0].Name).To(Equal("root"))
-	g.Expect(generated.Users[0].SSHAuthorizedKeys).To(HaveLen(1))
-	g.Expect(generated.Users[0].SSHAuthorizedKeys[0]).To(Equal(testKey))
+	g.Expect(generated.Users[0].SSHAuthorizedKeys).To(Equal(testKeys))
 }
 
-func Test_CreateUserData_withSSHKey_readFileFails(t *testing.T) {
+func Test_CreateMetadata(t *testing.T) {
 	g := NewWithT(t)
 
 	var (
-		testName = "foo"
-		testPath = "noexist"
+		testName      = "foo"
+		testNamespace = "bar"
 	)
 
-	_, err := microvm.CreateUserData(testName, testPath)
-	g.Expect(err).To(HaveOccurred())
-	g.Expect(err).To(BeAssignableToTypeOf(&fs.PathError{}))
+	out, err := microvm.CreateMetadata(testName, testNamespace)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	dat, err := base64.StdEncoding.DecodeString(out)
+	g.Expect(err).NotTo(HaveOccurred())
+	generated := map[string]string{}
+	g.Expect(yaml.Unmarshal(dat, generated)).To(Succeed())
+
+	g.Expect(generated).To(HaveKeyWithValue("instance_id", fmt.Sprintf("%s/%s", testNamespace, testName)))
+	g.Expect(generated).To(HaveKeyWithValue("local_hostname", testName))
+	g.Expect(generated).To(HaveKeyWithValue("platform", "liquid_metal"))
 }
 
-func Test_CreateMetadata(t *testing.T) {
+func Test_CreateMetadata_withOptions(t *testing.T) {
 	g := NewWithT(t)
 
 	var (
@@ -90,7 +81,15 @@ func Test_CreateMetadata(t *testing.T) {
 		testNamespace = "bar"
 	)
 
-	out, err := microvm.CreateMetadata(testName, testNamespace)
+	out, err := microvm.CreateMetadata(testName, testNamespace,
+		microvm.WithCloudName("lab"),
+		microvm.WithAvailabilityZone("az1"),
+		microvm.WithAvailabilityZone(""),
+		microvm.WithMetadata(map[string]string{
+			"rack":     "r12",
+			"platform": "custom",
+		}),
+	)
 	g.Expect(err).NotTo(HaveOccurred())
 
 	dat, err := base64.StdEncoding.DecodeString(out)
@@ -99,6 +98,8 @@ func Test_CreateMetadata(t *testing.T) {
 	g.Expect(yaml.Unmarshal(dat, generated)).To(Succeed())
 
 	g.Expect(generated).To(HaveKeyWithValue("instance_id", fmt.Sprintf("%s/%s", testNamespace, testName)))
-	g.Expect(generated).To(HaveKeyWithValue("local_hostname", testName))
-	g.Expect(generated).To(HaveKeyWithValue("platform", "liquid_metal"))
+	g.Expect(generated).To(HaveKeyWithValue("cloud_name", "lab"))
+	g.Expect(generated).To(HaveKeyWithValue("availability_zone", "az1"))
+	g.Expect(generated).To(HaveKeyWithValue("rack", "r12"))
+	g.Expect(generated).To(HaveKeyWithValue("platform", "custom"))
 }
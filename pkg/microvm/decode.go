@@ -0,0 +1,156 @@
+package microvm
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"gopkg.in/yaml.v2"
+
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+// Redacted replaces sensitive values in decoded metadata.
+const Redacted = "REDACTED"
+
+var (
+	sensitiveKey  = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|private|credential)`)
+	sensitiveLine = regexp.MustCompile(
+		`(?im)^(\s*-?\s*"?[\w-]*(passw(or)?d|secret|token|private|credential)[\w-]*"?\s*[:=]\s*)\S.*$`,
+	)
+)
+
+// DecodeMetadata decodes the base64 encoded values of a Microvm's metadata.
+// YAML documents (eg. `#cloud-config` user-data, meta-data and network-config)
+// are returned as maps, anything else (eg. shell scripts or multipart MIME) as
+// plain text. Values which are not base64 encoded are returned untouched.
+//
+// Unless reveal is true, secrets such as password hashes are redacted.
+func DecodeMetadata(metadata map[string]string, reveal bool) map[string]interface{} {
+	out := map[string]interface{}{}
+
+	for key, value := range metadata {
+		data, structured := decodeValue(value, reveal)
+		if structured != nil {
+			out[key] = structured
+
+			continue
+		}
+
+		out[key] = data
+	}
+
+	return out
+}
+
+// DecodeSpec returns the spec as a map, with the metadata swapped for its
+// decoded form (see DecodeMetadata).
+func DecodeSpec(spec *types.MicroVMSpec, reveal bool) (map[string]interface{}, error) {
+	dat, err := json.Marshal(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]interface{}{}
+	if err := json.Unmarshal(dat, &out); err != nil {
+		return nil, err
+	}
+
+	out["metadata"] = DecodeMetadata(spec.Metadata, reveal)
+
+	return out, nil
+}
+
+// FormatMetadata decodes a Microvm's metadata like DecodeMetadata, and returns
+// it as human readable text with one section per key.
+func FormatMetadata(metadata map[string]string, reveal bool) (string, error) {
+	keys := make([]string, 0, len(metadata))
+	for key := range metadata {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	builder := &strings.Builder{}
+
+	for _, key := range keys {
+		data, structured := decodeValue(metadata[key], reveal)
+
+		if structured != nil {
+			out, err := yaml.Marshal(structured)
+			if err != nil {
+				return "", fmt.Errorf("marshalling %s: %w", key, err)
+			}
+
+			data = string(out)
+			if isCloudConfig(decoded(metadata[key])) {
+				data = cloudConfigHeader + "\n" + data
+			}
+		}
+
+		fmt.Fprintf(builder, "--- %s\n%s", key, data)
+
+		if !strings.HasSuffix(data, "\n") {
+			builder.WriteString("\n")
+		}
+	}
+
+	return builder.String(), nil
+}
+
+// decodeValue returns the decoded text of the value, and the parsed document if
+// it is structured YAML.
+func decodeValue(value string, reveal bool) (string, map[string]interface{}) {
+	data := decoded(value)
+
+	if !isMultipart(data) {
+		doc := map[interface{}]interface{}{}
+		if err := yaml.Unmarshal(data, &doc); err == nil && len(doc) > 0 {
+			structured := utils.StringKeys(doc).(map[string]interface{})
+			if !reveal {
+				redact(structured)
+			}
+
+			return string(data), structured
+		}
+	}
+
+	text := string(data)
+	if !reveal {
+		text = sensitiveLine.ReplaceAllString(text, "${1}"+Redacted)
+	}
+
+	return text, nil
+}
+
+func decoded(value string) []byte {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return []byte(value)
+	}
+
+	return data
+}
+
+func redact(in interface{}) {
+	switch val := in.(type) {
+	case map[string]interface{}:
+		for k, v := range val {
+			if _, isBool := v.(bool); sensitiveKey.MatchString(k) && !isBool {
+				val[k] = Redacted
+
+				continue
+			}
+
+			redact(v)
+		}
+	case []interface{}:
+		for _, v := range val {
+			redact(v)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package microvm_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/microvm"
+)
+
+const testSecretCloudConfig = `#cloud-config
+hostname: foo
+users:
+  - name: root
+    lock_passwd: false
+    hashed_passwd: $6$rounds=4096$abc
+chpasswd:
+  list: |
+    root:secret
+`
+
+func Test_DecodeMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	metadata := map[string]string{
+		"user-data": encode(testSecretCloudConfig),
+		"meta-data": encode("instance_id: bar/foo\n"),
+		"script":    encode("#!/bin/sh\nPASSWORD=hunter2\necho hello\n"),
+		"plain":     "not base64!",
+	}
+
+	out := microvm.DecodeMetadata(metadata, false)
+
+	g.Expect(out["meta-data"]).To(Equal(map[string]interface{}{"instance_id": "bar/foo"}))
+	g.Expect(out["plain"]).To(Equal("not base64!"))
+	g.Expect(out["script"]).To(Equal("#!/bin/sh\nPASSWORD=REDACTED\necho hello\n"))
+
+	userData, ok := out["user-data"].(map[string]interface{})
+	g.Expect(ok).To(BeTrue())
+	g.Expect(userData["hostname"]).To(Equal("foo"))
+	g.Expect(userData["chpasswd"]).To(Equal(microvm.Redacted))
+
+	user := userData["users"].([]interface{})[0].(map[string]interface{})
+	g.Expect(user["hashed_passwd"]).To(Equal(microvm.Redacted))
+	g.Expect(user["lock_passwd"]).To(BeFalse())
+}
+
+func Test_DecodeMetadata_reveal(t *testing.T) {
+	g := NewWithT(t)
+
+	metadata := map[string]string{
+		"user-data": encode(testSecretCloudConfig),
+	}
+
+	out := microvm.DecodeMetadata(metadata, true)
+
+	user := out["user-data"].(map[string]interface{})["users"].([]interface{})[0].(map[string]interface{})
+	g.Expect(user["hashed_passwd"]).To(Equal("$6$rounds=4096$abc"))
+}
+
+func Test_FormatMetadata(t *testing.T) {
+	g := NewWithT(t)
+
+	metadata := map[string]string{
+		"user-data": encode("#cloud-config\nhostname: foo\npassword: hunter2\n"),
+		"meta-data": encode("instance_id: bar/foo\n"),
+	}
+
+	out, err := microvm.FormatMetadata(metadata, false)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal(`--- meta-data
+instance_id: bar/foo
+--- user-data
+#cloud-config
+hostname: foo
+password: REDACTED
+`))
+}
+
+func encode(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
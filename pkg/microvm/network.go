@@ -0,0 +1,108 @@
+package microvm
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	networkConfigVersion = 2
+
+	defaultRouteV4 = "0.0.0.0/0"
+	defaultRouteV6 = "::/0"
+)
+
+type networkConfig struct {
+	Version   int                 `yaml:"version"`
+	Ethernets map[string]ethernet `yaml:"ethernets"`
+}
+
+type ethernet struct {
+	Match       *match       `yaml:"match,omitempty"`
+	SetName     string       `yaml:"set-name,omitempty"`
+	DHCP4       bool         `yaml:"dhcp4"`
+	Addresses   []string     `yaml:"addresses,omitempty"`
+	Routes      []route      `yaml:"routes,omitempty"`
+	Nameservers *nameservers `yaml:"nameservers,omitempty"`
+}
+
+type match struct {
+	MACAddress string `yaml:"macaddress"`
+}
+
+type route struct {
+	To  string `yaml:"to"`
+	Via string `yaml:"via"`
+}
+
+type nameservers struct {
+	Addresses []string `yaml:"addresses"`
+}
+
+// CreateNetworkConfig generates a base64 encoded cloud-init network-config
+// (version 2) document from the given interfaces. Interfaces with a static
+// address are configured with that address, gateway and nameservers; all
+// others fall back to DHCP.
+func CreateNetworkConfig(interfaces []*types.NetworkInterface) (string, error) {
+	cfg := networkConfig{
+		Version:   networkConfigVersion,
+		Ethernets: map[string]ethernet{},
+	}
+
+	for _, iface := range interfaces {
+		eth, err := ethernetFor(iface)
+		if err != nil {
+			return "", err
+		}
+
+		cfg.Ethernets[iface.DeviceId] = eth
+	}
+
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("unable to marshal network-config: %w", err)
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+func ethernetFor(iface *types.NetworkInterface) (ethernet, error) {
+	eth := ethernet{}
+
+	if iface.GuestMac != nil {
+		eth.Match = &match{MACAddress: *iface.GuestMac}
+		eth.SetName = iface.DeviceId
+	}
+
+	if iface.Address == nil {
+		eth.DHCP4 = true
+
+		return eth, nil
+	}
+
+	ip, _, err := net.ParseCIDR(iface.Address.Address)
+	if err != nil {
+		return ethernet{}, fmt.Errorf("interface %s: invalid static address %q: %w", iface.DeviceId, iface.Address.Address, err)
+	}
+
+	eth.Addresses = []string{iface.Address.Address}
+
+	if iface.Address.Gateway != nil {
+		to := defaultRouteV4
+		if ip.To4() == nil {
+			to = defaultRouteV6
+		}
+
+		eth.Routes = []route{{To: to, Via: *iface.Address.Gateway}}
+	}
+
+	if len(iface.Address.Nameservers) > 0 {
+		eth.Nameservers = &nameservers{Addresses: iface.Address.Nameservers}
+	}
+
+	return eth, nil
+}
@@ -0,0 +1,87 @@
+package microvm_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"k8s.io/utils/pointer"
+
+	"github.com/warehouse-13/hammertime/pkg/microvm"
+)
+
+func Test_CreateNetworkConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	interfaces := []*types.NetworkInterface{
+		{
+			DeviceId: "eth1",
+			GuestMac: pointer.String("aa:bb:cc:dd:ee:ff"),
+			Address: &types.StaticAddress{
+				Address:     "192.168.100.10/24",
+				Gateway:     pointer.String("192.168.100.1"),
+				Nameservers: []string{"8.8.8.8"},
+			},
+		},
+		{
+			DeviceId: "eth2",
+			Address: &types.StaticAddress{
+				Address: "fd00::10/64",
+				Gateway: pointer.String("fd00::1"),
+			},
+		},
+		{
+			DeviceId: "eth3",
+		},
+	}
+
+	out, err := microvm.CreateNetworkConfig(interfaces)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	dat, err := base64.StdEncoding.DecodeString(out)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	expected := `version: 2
+ethernets:
+  eth1:
+    match:
+      macaddress: aa:bb:cc:dd:ee:ff
+    set-name: eth1
+    dhcp4: false
+    addresses:
+    - 192.168.100.10/24
+    routes:
+    - to: 0.0.0.0/0
+      via: 192.168.100.1
+    nameservers:
+      addresses:
+      - 8.8.8.8
+  eth2:
+    dhcp4: false
+    addresses:
+    - fd00::10/64
+    routes:
+    - to: ::/0
+      via: fd00::1
+  eth3:
+    dhcp4: true
+`
+	g.Expect(string(dat)).To(Equal(expected))
+}
+
+func Test_CreateNetworkConfig_invalidAddress(t *testing.T) {
+	g := NewWithT(t)
+
+	interfaces := []*types.NetworkInterface{
+		{
+			DeviceId: "eth1",
+			Address: &types.StaticAddress{
+				Address: "192.168.100.10",
+			},
+		},
+	}
+
+	_, err := microvm.CreateNetworkConfig(interfaces)
+	g.Expect(err).To(MatchError(ContainSubstring("interface eth1: invalid static address")))
+}
@@ -0,0 +1,138 @@
+package microvm
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+)
+
+// SSHAuthSockEnv is the environment variable pointing at the running ssh-agent.
+const SSHAuthSockEnv = "SSH_AUTH_SOCK"
+
+var errPrivateKey = errors.New("contains a private key, use the public (.pub) key instead")
+
+// LoadSSHKeys collects public keys from the given files and, if useAgent is
+// true, from the running ssh-agent. Files may be in `authorized_keys` format
+// with one key per line (which includes the `https://github.com/<user>.keys`
+// format). Every key is validated and duplicates are removed.
+func LoadSSHKeys(paths []string, useAgent bool) ([]string, error) {
+	keys := []string{}
+
+	for _, path := range paths {
+		fileKeys, err := getKeysFromPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, fileKeys...)
+	}
+
+	if useAgent {
+		agentKeys, err := getKeysFromAgent(os.Getenv(SSHAuthSockEnv))
+		if err != nil {
+			return nil, err
+		}
+
+		keys = append(keys, agentKeys...)
+	}
+
+	return dedupe(keys), nil
+}
+
+func getKeysFromPath(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := parseAuthorizedKeys(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%s: no public keys found", path)
+	}
+
+	return keys, nil
+}
+
+func parseAuthorizedKeys(data []byte) ([]string, error) {
+	if bytes.Contains(data, []byte("PRIVATE KEY-----")) {
+		return nil, errPrivateKey
+	}
+
+	keys := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if _, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line)); err != nil { //nolint: dogsled // we only validate
+			return nil, fmt.Errorf("line %d: invalid public key: %w", lineNum, err)
+		}
+
+		keys = append(keys, line)
+	}
+
+	return keys, scanner.Err()
+}
+
+func getKeysFromAgent(socket string) ([]string, error) {
+	if socket == "" {
+		return nil, fmt.Errorf("%s is not set, is ssh-agent running?", SSHAuthSockEnv)
+	}
+
+	conn, err := net.Dial("unix", socket)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to ssh-agent: %w", err)
+	}
+
+	defer conn.Close()
+
+	agentKeys, err := agent.NewClient(conn).List()
+	if err != nil {
+		return nil, fmt.Errorf("listing ssh-agent keys: %w", err)
+	}
+
+	if len(agentKeys) == 0 {
+		return nil, errors.New("ssh-agent has no keys")
+	}
+
+	keys := []string{}
+	for _, key := range agentKeys {
+		keys = append(keys, key.String())
+	}
+
+	return keys, nil
+}
+
+func dedupe(keys []string) []string {
+	seen := map[string]bool{}
+	out := []string{}
+
+	for _, key := range keys {
+		if seen[key] {
+			continue
+		}
+
+		seen[key] = true
+
+		out = append(out, key)
+	}
+
+	return out
+}
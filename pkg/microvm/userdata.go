@@ -0,0 +1,258 @@
+package microvm
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	cloudConfigHeader = "#cloud-config"
+	cloudConfigType   = "text/cloud-config"
+
+	// mergeType is the cloud-init merge strategy applied when combining the
+	// generated user-data with a user supplied file: dicts are merged
+	// recursively, lists are appended to and scalars from the later part win.
+	mergeType = "dict(recurse_array)+list(append)+str()"
+)
+
+// CreateUserDataFromFile builds base64 encoded user-data from the cloud-init
+// file at path. The file may be a `#cloud-config` YAML document, or a multipart
+// MIME document combining several cloud-init parts.
+//
+// When merge is false the file is passed through verbatim. When merge is true
+// the generated hostname and SSH data (see CreateUserData) are merged with the
+// file using cloud-init merge semantics. Values set in the file take
+// precedence over generated ones.
+func CreateUserDataFromFile(name string, sshKeys []string, path string, merge bool) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+
+	if !merge {
+		return base64.StdEncoding.EncodeToString(data), nil
+	}
+
+	generated, err := CreateUserData(name, sshKeys)
+	if err != nil {
+		return "", err
+	}
+
+	genData, err := base64.StdEncoding.DecodeString(generated)
+	if err != nil {
+		return "", fmt.Errorf("decoding generated user-data: %w", err)
+	}
+
+	var merged []byte
+
+	switch {
+	case isCloudConfig(data):
+		merged, err = mergeCloudConfig(genData, data)
+	case isMultipart(data):
+		merged, err = mergeMultipart(genData, data)
+	default:
+		return "", fmt.Errorf("user-data file %s must be #cloud-config or multipart MIME to be merged", path)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(merged), nil
+}
+
+// mergeCloudConfig merges the override cloud-config document on top of the base
+// document.
+func mergeCloudConfig(base, override []byte) ([]byte, error) {
+	baseMap := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(base, &baseMap); err != nil {
+		return nil, fmt.Errorf("unmarshalling generated user-data: %w", err)
+	}
+
+	overrideMap := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(override, &overrideMap); err != nil {
+		return nil, fmt.Errorf("unmarshalling user-data file: %w", err)
+	}
+
+	data, err := yaml.Marshal(mergeMaps(baseMap, overrideMap))
+	if err != nil {
+		return nil, fmt.Errorf("marshalling merged user-data: %w", err)
+	}
+
+	return append([]byte(cloudConfigHeader+"\n"), data...), nil
+}
+
+// mergeMaps recursively merges src into dst following the mergeType strategy,
+// and returns dst.
+func mergeMaps(dst, src map[interface{}]interface{}) map[interface{}]interface{} {
+	for key, srcVal := range src {
+		dstVal, ok := dst[key]
+		if !ok {
+			dst[key] = srcVal
+
+			continue
+		}
+
+		switch s := srcVal.(type) {
+		case map[interface{}]interface{}:
+			if d, ok := dstVal.(map[interface{}]interface{}); ok {
+				dst[key] = mergeMaps(d, s)
+
+				continue
+			}
+		case []interface{}:
+			if d, ok := dstVal.([]interface{}); ok {
+				dst[key] = append(d, s...)
+
+				continue
+			}
+		}
+
+		dst[key] = srcVal
+	}
+
+	return dst
+}
+
+// mergeMultipart appends the generated cloud-config as an extra part to the
+// multipart MIME document, instructing cloud-init to merge it with the other
+// cloud-config parts. The generated part is added first so that parts from the
+// document take precedence. The document's own headers are kept, and the
+// result uses CRLF line endings throughout, as RFC 2046 requires.
+func mergeMultipart(generated, doc []byte) ([]byte, error) {
+	msg, err := mail.ReadMessage(bufio.NewReader(bytes.NewReader(doc)))
+	if err != nil {
+		return nil, fmt.Errorf("reading multipart user-data: %w", err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("parsing multipart user-data content type: %w", err)
+	}
+
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return nil, fmt.Errorf("expected multipart user-data, got %s", mediaType)
+	}
+
+	out := &bytes.Buffer{}
+	writer := multipart.NewWriter(out)
+
+	if err := writer.SetBoundary(params["boundary"]); err != nil {
+		return nil, err
+	}
+
+	writeHeader(out, msg.Header)
+
+	if err := writePart(writer, textproto.MIMEHeader{
+		"Content-Type": {cloudConfigType},
+		"Merge-Type":   {mergeType},
+	}, generated); err != nil {
+		return nil, err
+	}
+
+	reader := multipart.NewReader(msg.Body, params["boundary"])
+
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("reading multipart user-data part: %w", err)
+		}
+
+		body, err := io.ReadAll(part)
+		if err != nil {
+			return nil, err
+		}
+
+		header := part.Header
+		if isCloudConfigPart(header) && header.Get("Merge-Type") == "" {
+			header.Set("Merge-Type", mergeType)
+		}
+
+		if err := writePart(writer, header, body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// writeHeader writes the top-level headers of a multipart document: the
+// Content-Type and MIME-Version first, then the rest in a stable order.
+func writeHeader(out *bytes.Buffer, header mail.Header) {
+	if header.Get("MIME-Version") == "" {
+		header["Mime-Version"] = []string{"1.0"}
+	}
+
+	keys := make([]string, 0, len(header))
+
+	for key := range header {
+		if key != "Content-Type" && key != "Mime-Version" {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	// net/mail canonicalises MIME-Version, so it is spelt out here
+	fmt.Fprintf(out, "Content-Type: %s\r\nMIME-Version: %s\r\n", header.Get("Content-Type"), header.Get("MIME-Version"))
+
+	for _, key := range keys {
+		for _, value := range header[key] {
+			fmt.Fprintf(out, "%s: %s\r\n", key, value)
+		}
+	}
+
+	out.WriteString("\r\n")
+}
+
+func writePart(writer *multipart.Writer, header textproto.MIMEHeader, body []byte) error {
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return err
+	}
+
+	_, err = part.Write(body)
+
+	return err
+}
+
+func isCloudConfigPart(header textproto.MIMEHeader) bool {
+	mediaType, _, err := mime.ParseMediaType(header.Get("Content-Type"))
+
+	return err == nil && mediaType == cloudConfigType
+}
+
+func isCloudConfig(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte(cloudConfigHeader))
+}
+
+func isMultipart(data []byte) bool {
+	msg, err := mail.ReadMessage(bufio.NewReader(bytes.NewReader(data)))
+	if err != nil {
+		return false
+	}
+
+	mediaType, _, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+
+	return err == nil && strings.HasPrefix(mediaType, "multipart/")
+}
@@ -0,0 +1,141 @@
+package microvm_test
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"gopkg.in/yaml.v2"
+
+	"github.com/warehouse-13/hammertime/pkg/microvm"
+)
+
+const (
+	testCloudConfig = `#cloud-config
+hostname: custom
+packages:
+  - curl
+users:
+  - name: ubuntu
+runcmd:
+  - echo hello
+`
+
+	testMultipart = `Content-Type: multipart/mixed; boundary="BOUNDARY"
+MIME-Version: 1.0
+Subject: web servers
+
+--BOUNDARY
+Content-Type: text/x-shellscript
+
+#!/bin/sh
+echo hello
+--BOUNDARY
+Content-Type: text/cloud-config
+
+#cloud-config
+packages:
+  - curl
+--BOUNDARY--
+`
+)
+
+func Test_CreateUserDataFromFile_verbatim(t *testing.T) {
+	g := NewWithT(t)
+
+	path := writeUserDataFile(t, testCloudConfig)
+
+	out, err := microvm.CreateUserDataFromFile("foo", nil, path, false)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	dat, err := base64.StdEncoding.DecodeString(out)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(dat)).To(Equal(testCloudConfig))
+}
+
+func Test_CreateUserDataFromFile_mergeCloudConfig(t *testing.T) {
+	g := NewWithT(t)
+
+	path := writeUserDataFile(t, testCloudConfig)
+
+	out, err := microvm.CreateUserDataFromFile("foo", nil, path, true)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	dat, err := base64.StdEncoding.DecodeString(out)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(string(dat)).To(HavePrefix("#cloud-config\n"))
+
+	merged := struct {
+		HostName     string   `yaml:"hostname"`
+		Packages     []string `yaml:"packages"`
+		FinalMessage string   `yaml:"final_message"`
+		RunCommands  []string `yaml:"runcmd"`
+		Users        []struct {
+			Name string `yaml:"name"`
+		} `yaml:"users"`
+	}{}
+	g.Expect(yaml.Unmarshal(dat, &merged)).To(Succeed())
+
+	g.Expect(merged.HostName).To(Equal("custom"))
+	g.Expect(merged.Packages).To(ConsistOf("curl"))
+	g.Expect(merged.RunCommands).To(ConsistOf("echo hello"))
+	g.Expect(merged.FinalMessage).NotTo(BeEmpty())
+	g.Expect(merged.Users).To(HaveLen(2))
+	g.Expect(merged.Users[0].Name).To(Equal("root"))
+	g.Expect(merged.Users[1].Name).To(Equal("ubuntu"))
+}
+
+func Test_CreateUserDataFromFile_mergeMultipart(t *testing.T) {
+	g := NewWithT(t)
+
+	path := writeUserDataFile(t, testMultipart)
+
+	out, err := microvm.CreateUserDataFromFile("foo", nil, path, true)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	dat, err := base64.StdEncoding.DecodeString(out)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	doc := string(dat)
+	g.Expect(doc).To(HavePrefix(
+		"Content-Type: multipart/mixed; boundary=\"BOUNDARY\"\r\nMIME-Version: 1.0\r\nSubject: web servers\r\n\r\n",
+	))
+	g.Expect(strings.Count(doc, "--BOUNDARY\r\n")).To(Equal(3))
+	g.Expect(strings.Count(doc, "Merge-Type: dict(recurse_array)+list(append)+str()")).To(Equal(2))
+	g.Expect(doc).To(ContainSubstring("hostname: foo"))
+	g.Expect(doc).To(ContainSubstring("#!/bin/sh\necho hello"))
+}
+
+func Test_CreateUserDataFromFile_mergeUnsupported(t *testing.T) {
+	g := NewWithT(t)
+
+	path := writeUserDataFile(t, "#!/bin/sh\necho hello\n")
+
+	_, err := microvm.CreateUserDataFromFile("foo", nil, path, true)
+	g.Expect(err).To(MatchError(ContainSubstring("must be #cloud-config or multipart MIME")))
+}
+
+func Test_CreateUserDataFromFile_readFileFails(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := microvm.CreateUserDataFromFile("foo", nil, "noexist", false)
+	g.Expect(err).To(HaveOccurred())
+}
+
+func writeUserDataFile(t *testing.T, content string) string {
+	g := NewWithT(t)
+
+	tempFile, err := ioutil.TempFile("", "userdata_test")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(ioutil.WriteFile(tempFile.Name(), []byte(content), 0755)).To(Succeed())
+
+	t.Cleanup(func() {
+		g.Expect(os.RemoveAll(tempFile.Name())).To(Succeed())
+	})
+
+	return tempFile.Name()
+}
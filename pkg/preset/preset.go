@@ -0,0 +1,243 @@
+package preset
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"gopkg.in/yaml.v2"
+
+	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+const (
+	// Default is the name of the preset used when none is selected.
+	Default = "default"
+	// BuiltIn is the Source of presets compiled into hammertime.
+	BuiltIn = "built-in"
+
+	// ConfigDirEnv can be set to override the hammertime config directory.
+	ConfigDirEnv = "HAMMERTIME_CONFIG_DIR"
+
+	presetsDir = "presets"
+)
+
+var extensions = []string{".yaml", ".yml", ".json"}
+
+// Preset is a named base MicroVMSpec which new Microvms are built from.
+type Preset struct {
+	// Name is the name the preset is selected with.
+	Name string `json:"name"`
+	// Source is the file the preset was loaded from, or BuiltIn.
+	Source string `json:"source"`
+	// Spec is the base spec, defining vcpu, memory, images, volumes and
+	// interfaces.
+	Spec *types.MicroVMSpec `json:"spec,omitempty"`
+	// Error is why the preset's file could not be loaded, if it could not.
+	// Such presets are only returned by List.
+	Error string `json:"error,omitempty"`
+}
+
+// ConfigDir returns the hammertime config directory. If dir is set it is
+// returned as is, otherwise ConfigDirEnv is checked before falling back to
+// `hammertime` under the user's config directory (eg. ~/.config/hammertime).
+func ConfigDir(dir string) (string, error) {
+	if utils.IsSet(dir) {
+		return dir, nil
+	}
+
+	if env := os.Getenv(ConfigDirEnv); utils.IsSet(env) {
+		return env, nil
+	}
+
+	userDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(userDir, "hammertime"), nil
+}
+
+// List returns the built-in presets and all presets found in the `presets`
+// directory under configDir, sorted by name. Presets in the directory override
+// built-in ones of the same name. A file which cannot be loaded is listed with
+// its Error rather than failing the whole list.
+func List(configDir string) ([]*Preset, error) {
+	presets := builtIn()
+
+	entries, err := os.ReadDir(filepath.Join(configDir, presetsDir))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		name, ok := presetName(entry.Name())
+		if entry.IsDir() || !ok {
+			continue
+		}
+
+		if preset, ok := presets[name]; ok && preset.Source != BuiltIn {
+			continue
+		}
+
+		path, err := file(configDir, name)
+		if err != nil {
+			return nil, err
+		}
+
+		preset, err := load(name, path)
+		if err != nil {
+			preset = &Preset{Name: name, Source: path, Error: err.Error()}
+		}
+
+		presets[name] = preset
+	}
+
+	out := make([]*Preset, 0, len(presets))
+	for _, preset := range presets {
+		out = append(out, preset)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	return out, nil
+}
+
+// Get returns the named preset from configDir, or the built-in one. If name is
+// empty the Default preset is returned. Only the preset's own file is read, so
+// other broken presets do not matter.
+func Get(configDir, name string) (*Preset, error) {
+	if !utils.IsSet(name) {
+		name = Default
+	}
+
+	if strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid preset name %q", name)
+	}
+
+	path, err := file(configDir, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if utils.IsSet(path) {
+		return load(name, path)
+	}
+
+	if preset, ok := builtIn()[name]; ok {
+		return preset, nil
+	}
+
+	names := []string{}
+
+	if presets, err := List(configDir); err == nil {
+		for _, preset := range presets {
+			names = append(names, preset.Name)
+		}
+	}
+
+	return nil, fmt.Errorf("preset %q not found, available presets: %s", name, strings.Join(names, ", "))
+}
+
+// file returns the path of the named preset's file under configDir, trying
+// each extension in turn, or "" if there is none.
+func file(configDir, name string) (string, error) {
+	for _, ext := range extensions {
+		path := filepath.Join(configDir, presetsDir, name+ext)
+
+		info, err := os.Stat(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+
+		if err != nil {
+			return "", err
+		}
+
+		if !info.IsDir() {
+			return path, nil
+		}
+	}
+
+	return "", nil
+}
+
+func builtIn() map[string]*Preset {
+	return map[string]*Preset{
+		Default: {
+			Name:   Default,
+			Source: BuiltIn,
+			Spec:   defaults.BaseMicroVM(),
+		},
+	}
+}
+
+// load reads a preset from a YAML or JSON file. The file uses the same field
+// names as a `--file` spec.
+func load(name, path string) (*Preset, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[interface{}]interface{}{}
+	if err := yaml.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("parsing preset %s: %w", path, err)
+	}
+
+	// Go through JSON so that the spec's field names are used.
+	dat, err := json.Marshal(utils.StringKeys(raw))
+	if err != nil {
+		return nil, fmt.Errorf("parsing preset %s: %w", path, err)
+	}
+
+	spec := &types.MicroVMSpec{}
+	if err := json.Unmarshal(dat, spec); err != nil {
+		return nil, fmt.Errorf("parsing preset %s: %w", path, err)
+	}
+
+	if err := validate(spec); err != nil {
+		return nil, fmt.Errorf("invalid preset %s: %w", path, err)
+	}
+
+	return &Preset{
+		Name:   name,
+		Source: path,
+		Spec:   spec,
+	}, nil
+}
+
+// validate checks the spec has what flintlock needs to boot a Microvm, so a
+// broken preset fails here rather than at create time.
+func validate(spec *types.MicroVMSpec) error {
+	switch {
+	case spec.Vcpu <= 0:
+		return errors.New("vcpu must be set")
+	case spec.MemoryInMb <= 0:
+		return errors.New("memory_in_mb must be set")
+	case spec.Kernel == nil || !utils.IsSet(spec.Kernel.Image):
+		return errors.New("kernel.image must be set")
+	case !utils.IsSet(spec.GetRootVolume().GetSource().GetContainerSource()):
+		return errors.New("root_volume.source.container_source must be set")
+	default:
+		return nil
+	}
+}
+
+func presetName(filename string) (string, bool) {
+	for _, ext := range extensions {
+		if strings.HasSuffix(filename, ext) {
+			return strings.TrimSuffix(filename, ext), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,189 @@
+package preset_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/defaults"
+	"github.com/warehouse-13/hammertime/pkg/preset"
+)
+
+const tinyPreset = `
+vcpu: 1
+memory_in_mb: 512
+kernel:
+  image: ghcr.io/weaveworks-liquidmetal/flintlock-kernel:5.10.77
+  filename: boot/vmlinux
+  add_network_config: true
+root_volume:
+  id: root
+  is_read_only: false
+  source:
+    container_source: ghcr.io/weaveworks-liquidmetal/capmvm-k8s-os:1.27.1
+interfaces:
+  - device_id: eth1
+    type: 0
+`
+
+func Test_ConfigDir(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(preset.ConfigDir("/etc/hammertime")).To(Equal("/etc/hammertime"))
+
+	t.Setenv(preset.ConfigDirEnv, "/from/env")
+	g.Expect(preset.ConfigDir("")).To(Equal("/from/env"))
+}
+
+func Test_List_builtInOnly(t *testing.T) {
+	g := NewWithT(t)
+
+	presets, err := preset.List(t.TempDir())
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(presets).To(HaveLen(1))
+	g.Expect(presets[0].Name).To(Equal(preset.Default))
+	g.Expect(presets[0].Source).To(Equal(preset.BuiltIn))
+	g.Expect(presets[0].Spec).To(Equal(defaults.BaseMicroVM()))
+}
+
+func Test_List_fromConfigDir(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	writePreset(t, dir, "tiny.yaml", tinyPreset)
+	writePreset(t, dir, "default.json", `{
+		"vcpu": 4, "memory_in_mb": 4096,
+		"kernel": {"image": "kernel"},
+		"root_volume": {"id": "root", "source": {"container_source": "os"}}
+	}`)
+	writePreset(t, dir, "README.md", "not a preset")
+
+	presets, err := preset.List(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(presets).To(HaveLen(2))
+
+	g.Expect(presets[0].Name).To(Equal("default"))
+	g.Expect(presets[0].Source).To(Equal(filepath.Join(dir, "presets", "default.json")))
+	g.Expect(presets[0].Spec.Vcpu).To(Equal(int32(4)))
+
+	tiny := presets[1]
+	g.Expect(tiny.Name).To(Equal("tiny"))
+	g.Expect(tiny.Spec.Vcpu).To(Equal(int32(1)))
+	g.Expect(tiny.Spec.MemoryInMb).To(Equal(int32(512)))
+	g.Expect(tiny.Spec.Kernel.Image).To(Equal("ghcr.io/weaveworks-liquidmetal/flintlock-kernel:5.10.77"))
+	g.Expect(tiny.Spec.RootVolume.Source.ContainerSource).To(
+		HaveValue(Equal("ghcr.io/weaveworks-liquidmetal/capmvm-k8s-os:1.27.1")),
+	)
+	g.Expect(tiny.Spec.Interfaces).To(HaveLen(1))
+	g.Expect(tiny.Spec.Interfaces[0].DeviceId).To(Equal("eth1"))
+}
+
+func Test_List_invalidPreset(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	writePreset(t, dir, "broken.yaml", "vcpu: [")
+	writePreset(t, dir, "tiny.yaml", tinyPreset)
+
+	presets, err := preset.List(dir)
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(presets).To(HaveLen(3))
+	g.Expect(presets[0].Name).To(Equal("broken"))
+	g.Expect(presets[0].Error).To(ContainSubstring("parsing preset"))
+	g.Expect(presets[0].Spec).To(BeNil())
+	g.Expect(presets[2].Name).To(Equal("tiny"))
+	g.Expect(presets[2].Error).To(BeEmpty())
+}
+
+func Test_Get_ignoresOtherPresets(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	writePreset(t, dir, "broken.yaml", "vcpu: [")
+	writePreset(t, dir, "tiny.yaml", tinyPreset)
+
+	p, err := preset.Get(dir, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.Source).To(Equal(preset.BuiltIn))
+
+	p, err = preset.Get(dir, "tiny")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.Spec.Vcpu).To(Equal(int32(1)))
+
+	_, err = preset.Get(dir, "broken")
+	g.Expect(err).To(MatchError(ContainSubstring("parsing preset")))
+}
+
+func Test_Get_invalidSpec(t *testing.T) {
+	tt := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "no vcpu",
+			content: `{"memory_in_mb": 512}`,
+			err:     "vcpu must be set",
+		},
+		{
+			name:    "no kernel",
+			content: `{"vcpu": 1, "memory_in_mb": 512}`,
+			err:     "kernel.image must be set",
+		},
+		{
+			name:    "no root volume",
+			content: `{"vcpu": 1, "memory_in_mb": 512, "kernel": {"image": "kernel"}}`,
+			err:     "root_volume.source.container_source must be set",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			dir := t.TempDir()
+			writePreset(t, dir, "bad.json", tc.content)
+
+			_, err := preset.Get(dir, "bad")
+			g.Expect(err).To(MatchError(ContainSubstring(tc.err)))
+		})
+	}
+}
+
+func Test_Get_invalidName(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := preset.Get(t.TempDir(), "../secrets")
+	g.Expect(err).To(MatchError(`invalid preset name "../secrets"`))
+}
+
+func Test_Get(t *testing.T) {
+	g := NewWithT(t)
+
+	dir := t.TempDir()
+	writePreset(t, dir, "tiny.yml", tinyPreset)
+
+	p, err := preset.Get(dir, "tiny")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.Spec.Vcpu).To(Equal(int32(1)))
+
+	p, err = preset.Get(dir, "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(p.Name).To(Equal(preset.Default))
+}
+
+func Test_Get_notFound(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := preset.Get(t.TempDir(), "huge")
+	g.Expect(err).To(MatchError(`preset "huge" not found, available presets: default`))
+}
+
+func writePreset(t *testing.T, dir, name, content string) {
+	g := NewWithT(t)
+
+	g.Expect(os.MkdirAll(filepath.Join(dir, "presets"), 0o755)).To(Succeed())
+	g.Expect(os.WriteFile(filepath.Join(dir, "presets", name), []byte(content), 0o600)).To(Succeed())
+}
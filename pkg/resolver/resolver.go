@@ -0,0 +1,146 @@
+package resolver
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/sdk"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+// MinPrefix is the shortest UID prefix which will be looked up.
+const MinPrefix = sdk.MinPrefix
+
+// Ref identifies the Microvms a command acts on: either by UID (or a prefix of
+// one), or by namespace and name.
+type Ref = sdk.Ref
+
+// Parse parses a positional argument: `namespace/name`, or otherwise a UID or
+// a unique prefix of one. Arguments starting with `-` are flags given after
+// the Microvm, which urfave/cli does not parse, so are rejected.
+func Parse(arg string) (Ref, error) {
+	if strings.HasPrefix(arg, "-") {
+		return Ref{}, exitcode.New(exitcode.Usage,
+			"unexpected flag %q, flags must come before <namespace/name|uid>", arg)
+	}
+
+	ns, name, isName := strings.Cut(arg, "/")
+	if !isName {
+		if !utils.IsSet(arg) {
+			return Ref{}, exitcode.New(exitcode.Usage, "invalid microvm %q, expected <namespace/name> or <uid>", arg)
+		}
+
+		return Ref{UID: arg}, nil
+	}
+
+	if !utils.IsSet(ns) || !utils.IsSet(name) || strings.Contains(name, "/") {
+		return Ref{}, exitcode.New(exitcode.Usage, "invalid microvm %q, expected <namespace/name> or <uid>", arg)
+	}
+
+	return Ref{Namespace: ns, Name: name}, nil
+}
+
+// Resolver finds the Microvms which Refs point to, with sdk.Find, and turns
+// failures into errors with exit codes.
+type Resolver struct {
+	client client.FlintlockClient
+}
+
+// New returns a Resolver which looks Microvms up with the client.
+func New(c client.FlintlockClient) *Resolver {
+	return &Resolver{client: c}
+}
+
+// Find returns the Microvms the ref points to, see sdk.Find.
+func (r *Resolver) Find(ref Ref) ([]*types.MicroVM, error) {
+	mvms, err := sdk.Find(context.Background(), finder{r.client}, ref)
+	if err != nil {
+		return nil, exitError(ref, err)
+	}
+
+	return mvms, nil
+}
+
+// One is Find for commands which act on a single Microvm. It is an error if
+// the ref matches none or several, in which case they are listed.
+func (r *Resolver) One(ref Ref) (*types.MicroVM, error) {
+	mvm, err := sdk.Resolve(context.Background(), finder{r.client}, ref)
+	if err != nil {
+		return nil, exitError(ref, err)
+	}
+
+	return mvm, nil
+}
+
+// Ambiguous returns the error for a ref which matched several Microvms where
+// only one will do, listing them.
+func Ambiguous(ref Ref, mvms []*types.MicroVM) error {
+	return exitError(ref, &sdk.AmbiguousError{Ref: ref, MicroVMs: mvms})
+}
+
+// exitError gives Microvms which could not be found or told apart the exit
+// code and message for the command line. Other errors are returned as they
+// are.
+func exitError(ref Ref, err error) error {
+	var ambiguous *sdk.AmbiguousError
+
+	switch {
+	case errors.As(err, &ambiguous) && utils.IsSet(ref.UID):
+		return exitcode.New(exitcode.Usage, "uid prefix %s is ambiguous, it matches %d MicroVMs:\n%s",
+			ref, len(ambiguous.MicroVMs), describeAll(ambiguous.MicroVMs))
+	case errors.As(err, &ambiguous):
+		return exitcode.New(exitcode.Usage, "%d MicroVMs found under %s, use a uid instead:\n%s",
+			len(ambiguous.MicroVMs), ref, describeAll(ambiguous.MicroVMs))
+	case errors.Is(err, sdk.ErrNotFound) && len(ref.UID) > 0 && len(ref.UID) < MinPrefix:
+		return exitcode.New(exitcode.NotFound,
+			"MicroVM %s not found (uid prefixes must be at least %d characters)", ref, MinPrefix)
+	case errors.Is(err, sdk.ErrNotFound):
+		return exitcode.New(exitcode.NotFound, "MicroVM %s not found", ref)
+	default:
+		return err
+	}
+}
+
+// finder adapts a FlintlockClient to an sdk.Finder.
+type finder struct {
+	client client.FlintlockClient
+}
+
+func (f finder) Get(_ context.Context, uid string) (*types.MicroVM, error) {
+	res, err := f.client.Get(uid)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetMicrovm(), nil
+}
+
+func (f finder) List(_ context.Context, namespace, name string) ([]*types.MicroVM, error) {
+	res, err := f.client.List(name, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetMicrovm(), nil
+}
+
+// Describe returns `namespace/name uid`, to list a Microvm by.
+func Describe(mvm *types.MicroVM) string {
+	return fmt.Sprintf("%s/%s %s", mvm.Spec.Namespace, mvm.Spec.Id, mvm.Spec.GetUid())
+}
+
+func describeAll(mvms []*types.MicroVM) string {
+	lines := make([]string, 0, len(mvms))
+
+	for _, mvm := range mvms {
+		lines = append(lines, "  "+Describe(mvm))
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,161 @@
+package resolver_test
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
+	"github.com/warehouse-13/hammertime/pkg/internal/fixtures"
+	"github.com/warehouse-13/hammertime/pkg/resolver"
+)
+
+func Test_Parse(t *testing.T) {
+	tt := []struct {
+		arg     string
+		want    resolver.Ref
+		wantErr bool
+	}{
+		{arg: "bar/foo", want: resolver.Ref{Namespace: "bar", Name: "foo"}},
+		{arg: "01GB7PW8", want: resolver.Ref{UID: "01GB7PW8"}},
+		{arg: "", wantErr: true},
+		{arg: "bar/", wantErr: true},
+		{arg: "/foo", wantErr: true},
+		{arg: "bar/foo/baz", wantErr: true},
+		{arg: "--force", wantErr: true},
+		{arg: "-n", wantErr: true},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.arg, func(t *testing.T) {
+			g := NewWithT(t)
+
+			ref, err := resolver.Parse(tc.arg)
+			if tc.wantErr {
+				g.Expect(err).To(HaveOccurred())
+				g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Usage))
+
+				return
+			}
+
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ref).To(Equal(tc.want))
+		})
+	}
+}
+
+func Test_Find_byName(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.ListReturns(fixtures.ListResponse(fixtures.MicroVM("bar", "foo", "aaa111"), fixtures.MicroVM("bar", "foo", "bbb222")), nil)
+
+	mvms, err := resolver.New(mockClient).Find(resolver.Ref{Namespace: "bar", Name: "foo"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvms).To(HaveLen(2))
+
+	name, ns := mockClient.ListArgsForCall(0)
+	g.Expect(name).To(Equal("foo"))
+	g.Expect(ns).To(Equal("bar"))
+	g.Expect(mockClient.GetCallCount()).To(BeZero())
+}
+
+func Test_Find_byUid(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.GetReturns(&v1alpha1.GetMicroVMResponse{Microvm: fixtures.MicroVM("bar", "foo", "aaa111")}, nil)
+
+	mvms, err := resolver.New(mockClient).Find(resolver.Ref{UID: "aaa111"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvms).To(HaveLen(1))
+	g.Expect(mockClient.ListCallCount()).To(BeZero())
+}
+
+func Test_Find_byUidPrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.GetReturns(nil, status.Error(codes.NotFound, "not found"))
+	mockClient.ListReturns(fixtures.ListResponse(fixtures.MicroVM("bar", "foo", "AAA111"), fixtures.MicroVM("bar", "baz", "bbb222")), nil)
+
+	mvms, err := resolver.New(mockClient).Find(resolver.Ref{UID: "aaa1"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvms).To(HaveLen(1))
+	g.Expect(mvms[0].Spec.GetUid()).To(Equal("AAA111"))
+
+	name, ns := mockClient.ListArgsForCall(0)
+	g.Expect(name).To(BeEmpty())
+	g.Expect(ns).To(BeEmpty())
+}
+
+func Test_Find_byUidPrefix_ambiguous(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.GetReturns(nil, status.Error(codes.NotFound, "not found"))
+	mockClient.ListReturns(fixtures.ListResponse(fixtures.MicroVM("bar", "foo", "aaa111"), fixtures.MicroVM("bar", "baz", "aaa122")), nil)
+
+	_, err := resolver.New(mockClient).Find(resolver.Ref{UID: "aaa1"})
+	g.Expect(err).To(MatchError(ContainSubstring("uid prefix aaa1 is ambiguous, it matches 2 MicroVMs")))
+	g.Expect(err).To(MatchError(ContainSubstring("bar/foo aaa111")))
+	g.Expect(err).To(MatchError(ContainSubstring("bar/baz aaa122")))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.Usage))
+}
+
+func Test_Find_byUidPrefix_tooShort(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.GetReturns(nil, status.Error(codes.NotFound, "not found"))
+
+	_, err := resolver.New(mockClient).Find(resolver.Ref{UID: "aaa"})
+	g.Expect(err).To(MatchError(ContainSubstring("uid prefixes must be at least 4 characters")))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.NotFound))
+	g.Expect(mockClient.ListCallCount()).To(BeZero())
+}
+
+func Test_Find_byUidPrefix_notFound(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.GetReturns(nil, status.Error(codes.NotFound, "not found"))
+	mockClient.ListReturns(fixtures.ListResponse(fixtures.MicroVM("bar", "foo", "aaa111")), nil)
+
+	_, err := resolver.New(mockClient).Find(resolver.Ref{UID: "ccc3"})
+	g.Expect(err).To(MatchError("MicroVM ccc3 not found"))
+	g.Expect(exitcode.FromError(err).Code).To(Equal(exitcode.NotFound))
+}
+
+func Test_Find_getFails(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.GetReturns(nil, errors.New("boom"))
+
+	_, err := resolver.New(mockClient).Find(resolver.Ref{UID: "aaa111"})
+	g.Expect(err).To(MatchError("boom"))
+	g.Expect(mockClient.ListCallCount()).To(BeZero())
+}
+
+func Test_One(t *testing.T) {
+	g := NewWithT(t)
+
+	mockClient := new(fakeclient.FakeFlintlockClient)
+	mockClient.ListReturnsOnCall(0, fixtures.ListResponse(), nil)
+	mockClient.ListReturnsOnCall(1, fixtures.ListResponse(fixtures.MicroVM("bar", "foo", "aaa111"), fixtures.MicroVM("bar", "foo", "bbb222")), nil)
+
+	ref := resolver.Ref{Namespace: "bar", Name: "foo"}
+
+	_, err := resolver.New(mockClient).One(ref)
+	g.Expect(err).To(MatchError("MicroVM bar/foo not found"))
+
+	_, err = resolver.New(mockClient).One(ref)
+	g.Expect(err).To(MatchError(ContainSubstring("2 MicroVMs found under bar/foo, use a uid instead")))
+	g.Expect(err).To(MatchError(ContainSubstring("bar/foo bbb222")))
+}
@@ -0,0 +1,7 @@
+package sdk
+
+// SetReads sends the client's Get and List calls to the server which other's
+// Create and Delete go to, as if its reads were load balanced.
+func (c *Client) SetReads(other *Client) {
+	c.reads = other.writes
+}
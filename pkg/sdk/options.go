@@ -0,0 +1,178 @@
+package sdk
+
+import (
+	"crypto/tls"
+	"net/url"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+)
+
+// DefaultPollInterval is how often Watch, and so the *AndWait helpers, fetch
+// the Microvm unless WithPollInterval is given.
+const DefaultPollInterval = time.Second
+
+// Option configures a Client, see New.
+type Option func(*options)
+
+type options struct {
+	token        string
+	tls          *tls.Config
+	dialTimeout  time.Duration
+	callTimeout  time.Duration
+	retries      int
+	retryBackoff time.Duration
+	proxy        *url.URL
+	sshJump      string
+	logger       *logr.Logger
+	interceptors []grpc.UnaryClientInterceptor
+	dialOptions  []grpc.DialOption
+	pollInterval time.Duration
+}
+
+// WithToken authenticates every call with the token, as flintlock's basic
+// auth expects.
+func WithToken(token string) Option {
+	return func(o *options) {
+		o.token = token
+	}
+}
+
+// WithTLS connects to the server over TLS with the given config. Without it
+// the connection is plaintext.
+func WithTLS(config *tls.Config) Option {
+	return func(o *options) {
+		o.tls = config
+	}
+}
+
+// WithDialTimeout makes New block until the server is reachable, and fail if
+// it is not within timeout. By default New returns straight away and the
+// connection is made on the first call.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = timeout
+	}
+}
+
+// WithCallTimeout gives up on any call, or attempt at one when retrying, which
+// takes longer than timeout. Deadlines on the call's context also apply.
+func WithCallTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.callTimeout = timeout
+	}
+}
+
+// WithRetries retries Get, List and Delete calls up to retries times when they
+// fail with a transient error, waiting backoff before the first retry and
+// doubling it for each one after. Create is never retried.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(o *options) {
+		o.retries = retries
+		o.retryBackoff = backoff
+	}
+}
+
+// WithProxy connects to the server through a socks5://, socks5h:// or http://
+// proxy, see dialler.ParseProxyURL.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(o *options) {
+		o.proxy = proxyURL
+	}
+}
+
+// WithSSHJump connects to the server through an ssh tunnel via the jump host
+// (eg. `user@bastion`), using the local ssh binary and config.
+func WithSSHJump(jump string) Option {
+	return func(o *options) {
+		o.sshJump = jump
+	}
+}
+
+// WithLogger logs each call, and each retry of one, to log. See
+// dialler.LoggingInterceptor for what is logged at each level.
+func WithLogger(log logr.Logger) Option {
+	return func(o *options) {
+		o.logger = &log
+	}
+}
+
+// WithInterceptors adds interceptors to every call. They run inside the
+// retries, timeout and logging set by other options, so see each attempt.
+func WithInterceptors(interceptors ...grpc.UnaryClientInterceptor) Option {
+	return func(o *options) {
+		o.interceptors = append(o.interceptors, interceptors...)
+	}
+}
+
+// WithDialOptions passes extra options to grpc when dialling. They are applied
+// last, so override those set by other options.
+func WithDialOptions(opts ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = append(o.dialOptions, opts...)
+	}
+}
+
+// WithPollInterval sets how often Watch fetches the Microvm.
+func WithPollInterval(interval time.Duration) Option {
+	return func(o *options) {
+		o.pollInterval = interval
+	}
+}
+
+// GRPCOptions returns the grpc dial options which opts amount to, for
+// connecting to flintlock's other services (eg. health) the same way New does.
+// The token is not included, as dialler.New takes it separately.
+func GRPCOptions(opts ...Option) []grpc.DialOption {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o.grpcOptions()
+}
+
+// grpcOptions returns the grpc options for the configured options.
+func (o *options) grpcOptions() []grpc.DialOption {
+	opts := []grpc.DialOption{}
+
+	if o.tls != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(o.tls)))
+	}
+
+	// Interceptors are chained outermost first: the retries wrap the timeout,
+	// so that it bounds each attempt, and each attempt is logged.
+	if o.retries > 0 {
+		opts = append(opts, dialler.WithRetries(o.retries, o.retryBackoff))
+	}
+
+	if o.callTimeout > 0 {
+		opts = append(opts, dialler.WithCallTimeout(o.callTimeout))
+	}
+
+	if o.logger != nil {
+		opts = append(opts, dialler.WithLogging(*o.logger))
+	}
+
+	if len(o.interceptors) > 0 {
+		opts = append(opts, grpc.WithChainUnaryInterceptor(o.interceptors...))
+	}
+
+	if o.proxy != nil {
+		opts = append(opts, dialler.WithProxy(o.proxy))
+	}
+
+	if o.sshJump != "" {
+		opts = append(opts, dialler.WithSSHJump(o.sshJump))
+	}
+
+	if o.dialTimeout > 0 {
+		opts = append(opts, grpc.WithBlock())
+	}
+
+	return append(opts, o.dialOptions...)
+}
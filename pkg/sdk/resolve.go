@@ -0,0 +1,162 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MinPrefix is the shortest UID prefix which Find will look up.
+const MinPrefix = 4
+
+var (
+	// ErrNotFound is returned by Find and Resolve when no Microvm matches.
+	ErrNotFound = errors.New("microvm not found")
+	// ErrAmbiguous is matched by the AmbiguousError returned when several
+	// Microvms match.
+	ErrAmbiguous = errors.New("several microvms found")
+)
+
+// IsNotFound reports whether err means there is no such Microvm, either from
+// Find and Resolve or from the server.
+func IsNotFound(err error) bool {
+	return errors.Is(err, ErrNotFound) || status.Code(err) == codes.NotFound
+}
+
+// AmbiguousError is returned when a Ref which must match a single Microvm
+// matches several. errors.Is(err, ErrAmbiguous) is true for it.
+type AmbiguousError struct {
+	Ref      Ref
+	MicroVMs []*types.MicroVM
+}
+
+func (e *AmbiguousError) Error() string {
+	return fmt.Sprintf("%s: %d match %s", ErrAmbiguous, len(e.MicroVMs), e.Ref)
+}
+
+func (e *AmbiguousError) Unwrap() error {
+	return ErrAmbiguous
+}
+
+// Ref identifies Microvms: either by UID (or a prefix of one), or by
+// namespace and name.
+type Ref struct {
+	Namespace string
+	Name      string
+	UID       string
+}
+
+func (r Ref) String() string {
+	if r.UID != "" {
+		return r.UID
+	}
+
+	return r.Namespace + "/" + r.Name
+}
+
+// Finder is what Find and Resolve look Microvms up with. Client is one, and
+// other clients can be adapted to it.
+type Finder interface {
+	Get(ctx context.Context, uid string) (*types.MicroVM, error)
+	List(ctx context.Context, namespace, name string) ([]*types.MicroVM, error)
+}
+
+// Find returns the Microvms the ref points to.
+//
+// A UID is fetched directly. If the server does not know it, it is taken as a
+// prefix (like a short git hash) and matched against a single List of all
+// Microvms: it must match exactly one, otherwise ErrNotFound or an
+// AmbiguousError is returned.
+//
+// A namespace and name is a single List, which may match several Microvms or
+// none. Either may be empty to match any.
+func Find(ctx context.Context, finder Finder, ref Ref) ([]*types.MicroVM, error) {
+	if ref.UID == "" {
+		return finder.List(ctx, ref.Namespace, ref.Name)
+	}
+
+	mvm, err := finder.Get(ctx, ref.UID)
+	if err == nil && mvm != nil {
+		return []*types.MicroVM{mvm}, nil
+	}
+
+	if err != nil && !unknown(err) {
+		return nil, err
+	}
+
+	mvm, err = byPrefix(ctx, finder, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*types.MicroVM{mvm}, nil
+}
+
+// Resolve is Find for a ref which must match a single Microvm: it is
+// ErrNotFound if it matches none, and an AmbiguousError if several.
+func Resolve(ctx context.Context, finder Finder, ref Ref) (*types.MicroVM, error) {
+	mvms, err := Find(ctx, finder, ref)
+	if err != nil {
+		return nil, err
+	}
+
+	switch len(mvms) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+	case 1:
+		return mvms[0], nil
+	default:
+		return nil, &AmbiguousError{Ref: ref, MicroVMs: mvms}
+	}
+}
+
+// Find returns the Microvms the ref points to, see the Find function.
+func (c *Client) Find(ctx context.Context, ref Ref) ([]*types.MicroVM, error) {
+	return Find(ctx, c, ref)
+}
+
+// Resolve returns the single Microvm the ref points to, see the Resolve
+// function.
+func (c *Client) Resolve(ctx context.Context, ref Ref) (*types.MicroVM, error) {
+	return Resolve(ctx, c, ref)
+}
+
+func byPrefix(ctx context.Context, finder Finder, ref Ref) (*types.MicroVM, error) {
+	if len(ref.UID) < MinPrefix {
+		return nil, fmt.Errorf("%w: %s (uid prefixes must be at least %d characters)", ErrNotFound, ref, MinPrefix)
+	}
+
+	mvms, err := finder.List(ctx, "", "")
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []*types.MicroVM{}
+
+	for _, mvm := range mvms {
+		if strings.HasPrefix(strings.ToLower(mvm.GetSpec().GetUid()), strings.ToLower(ref.UID)) {
+			matches = append(matches, mvm)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return nil, &AmbiguousError{Ref: ref, MicroVMs: matches}
+	}
+}
+
+// unknown reports whether the server did not recognise the uid.
+func unknown(err error) bool {
+	code := status.Code(err)
+
+	return code == codes.NotFound || code == codes.InvalidArgument
+}
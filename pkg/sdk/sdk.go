@@ -0,0 +1,193 @@
+// Package sdk is a Go client for flintlock servers, and what the hammertime
+// commands are built on.
+//
+//	client, err := sdk.New("127.0.0.1:9090",
+//		sdk.WithToken(token),
+//		sdk.WithRetries(3, 250*time.Millisecond),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer client.Close()
+//
+//	mvm, err := client.CreateAndWait(ctx, spec)
+//
+// Errors from the server are gRPC status errors, so can be inspected with
+// status.Code.
+package sdk
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc"
+	"k8s.io/utils/pointer"
+
+	"github.com/warehouse-13/hammertime/pkg/dialler"
+)
+
+// Client calls a flintlock server. It is safe for concurrent use.
+type Client struct {
+	writes v1alpha1.MicroVMClient
+	reads  v1alpha1.MicroVMClient
+	conns  []*grpc.ClientConn
+
+	pollInterval time.Duration
+}
+
+// New returns a Client for the server at address, which is a host:port,
+// unix:///path/to/socket or dns+srv://_service._proto.domain. An SRV name is
+// resolved to all of the hosts in its records: Get and List are load balanced
+// across them, while Create and Delete go to the preferred one.
+//
+// The Client must be closed when no longer needed.
+func New(address string, opts ...Option) (*Client, error) {
+	o := &options{pollInterval: DefaultPollInterval}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	if err := dialler.ValidateAddress(address); err != nil {
+		return nil, err
+	}
+
+	c := &Client{pollInterval: o.pollInterval}
+
+	conn, err := dial(address, o, o.grpcOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	c.conns = append(c.conns, conn)
+	c.writes = v1alpha1.NewMicroVMClient(conn)
+	c.reads = c.writes
+
+	if dialler.IsLoadBalanced(address) {
+		readsConn, err := dial(address, o, append(o.grpcOptions(), dialler.WithRoundRobin()))
+		if err != nil {
+			c.Close()
+
+			return nil, err
+		}
+
+		c.conns = append(c.conns, readsConn)
+		c.reads = v1alpha1.NewMicroVMClient(readsConn)
+	}
+
+	return c, nil
+}
+
+func dial(address string, o *options, grpcOpts []grpc.DialOption) (*grpc.ClientConn, error) {
+	ctx := context.Background()
+
+	if o.dialTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, o.dialTimeout)
+		defer cancel()
+	}
+
+	return dialler.NewWithContext(ctx, address, o.token, grpcOpts)
+}
+
+// Conn returns the connection Create and Delete are made on, for calling
+// flintlock's other services. It is closed by Close.
+func (c *Client) Conn() *grpc.ClientConn {
+	return c.conns[0]
+}
+
+// Close closes the connections to the server.
+func (c *Client) Close() error {
+	var err error
+
+	for _, conn := range c.conns {
+		if closeErr := conn.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+
+	return err
+}
+
+// Create creates a new Microvm from the spec. It returns as soon as flintlock
+// has accepted it, see CreateAndWait to wait until it is running.
+func (c *Client) Create(ctx context.Context, spec *types.MicroVMSpec) (*types.MicroVM, error) {
+	res, err := c.writes.CreateMicroVM(ctx, &v1alpha1.CreateMicroVMRequest{Microvm: spec})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetMicrovm(), nil
+}
+
+// Get fetches a Microvm by its uid.
+func (c *Client) Get(ctx context.Context, uid string) (*types.MicroVM, error) {
+	return get(ctx, c.reads, uid)
+}
+
+func get(ctx context.Context, mvms v1alpha1.MicroVMClient, uid string) (*types.MicroVM, error) {
+	res, err := mvms.GetMicroVM(ctx, &v1alpha1.GetMicroVMRequest{Uid: uid})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetMicrovm(), nil
+}
+
+// List fetches the Microvms in a namespace, optionally only those with a name.
+// Either may be empty to match any.
+func (c *Client) List(ctx context.Context, namespace, name string) ([]*types.MicroVM, error) {
+	res, err := c.reads.ListMicroVMs(ctx, &v1alpha1.ListMicroVMsRequest{
+		Namespace: namespace,
+		Name:      pointer.String(name),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res.GetMicrovm(), nil
+}
+
+// ListStream fetches the same Microvms as List over the ListMicroVMsStream
+// RPC, calling fn with each as it arrives, so it is not limited by the size of
+// a single response. Returning an error from fn stops the stream. Streams are
+// not retried, nor bounded by WithCallTimeout: use ctx for a deadline.
+func (c *Client) ListStream(ctx context.Context, namespace, name string, fn func(*types.MicroVM) error) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	stream, err := c.reads.ListMicroVMsStream(ctx, &v1alpha1.ListMicroVMsRequest{
+		Namespace: namespace,
+		Name:      pointer.String(name),
+	})
+	if err != nil {
+		return err
+	}
+
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err := fn(msg.GetMicrovm()); err != nil {
+			return err
+		}
+	}
+}
+
+// Delete deletes a Microvm by its uid. It returns as soon as flintlock has
+// accepted the request, see DeleteAndWait to wait until it is gone.
+func (c *Client) Delete(ctx context.Context, uid string) error {
+	_, err := c.writes.DeleteMicroVM(ctx, &v1alpha1.DeleteMicroVMRequest{Uid: uid})
+
+	return err
+}
@@ -0,0 +1,460 @@
+package sdk_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"k8s.io/utils/pointer"
+
+	"github.com/warehouse-13/hammertime/pkg/sdk"
+)
+
+// fakeServer keeps microvms in memory. Each Get of a microvm moves it on to
+// the next of states; a deleted one is gone after one more Get.
+type fakeServer struct {
+	v1alpha1.UnimplementedMicroVMServer
+
+	states []types.MicroVMStatus_MicroVMState
+
+	mu   sync.Mutex
+	mvms map[string]*types.MicroVM
+	gets map[string]int
+	auth []string
+}
+
+func newFakeServer(states ...types.MicroVMStatus_MicroVMState) *fakeServer {
+	return &fakeServer{states: states, mvms: map[string]*types.MicroVM{}, gets: map[string]int{}}
+}
+
+func (s *fakeServer) CreateMicroVM(
+	ctx context.Context, req *v1alpha1.CreateMicroVMRequest,
+) (*v1alpha1.CreateMicroVMResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	s.auth = append(s.auth, md.Get("authorization")...)
+
+	spec := req.Microvm
+	spec.Uid = pointer.String(fmt.Sprintf("uid-%d", len(s.mvms)))
+
+	mvm := &types.MicroVM{Spec: spec, Status: &types.MicroVMStatus{State: types.MicroVMStatus_PENDING}}
+	s.mvms[spec.GetUid()] = mvm
+
+	return &v1alpha1.CreateMicroVMResponse{Microvm: clone(mvm)}, nil
+}
+
+func (s *fakeServer) GetMicroVM(
+	_ context.Context, req *v1alpha1.GetMicroVMRequest,
+) (*v1alpha1.GetMicroVMResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	mvm, ok := s.mvms[req.Uid]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "not found")
+	}
+
+	if mvm.Status.State == types.MicroVMStatus_DELETING {
+		delete(s.mvms, req.Uid)
+	} else if n := s.gets[req.Uid]; n < len(s.states) {
+		mvm.Status.State = s.states[n]
+		s.gets[req.Uid]++
+	}
+
+	return &v1alpha1.GetMicroVMResponse{Microvm: clone(mvm)}, nil
+}
+
+func (s *fakeServer) ListMicroVMs(
+	_ context.Context, req *v1alpha1.ListMicroVMsRequest,
+) (*v1alpha1.ListMicroVMsResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := &v1alpha1.ListMicroVMsResponse{}
+
+	for _, mvm := range s.mvms {
+		if (req.Namespace == "" || req.Namespace == mvm.Spec.Namespace) &&
+			(req.GetName() == "" || req.GetName() == mvm.Spec.Id) {
+			res.Microvm = append(res.Microvm, clone(mvm))
+		}
+	}
+
+	return res, nil
+}
+
+func (s *fakeServer) ListMicroVMsStream(
+	req *v1alpha1.ListMicroVMsRequest, stream v1alpha1.MicroVM_ListMicroVMsStreamServer,
+) error {
+	res, err := s.ListMicroVMs(stream.Context(), req)
+	if err != nil {
+		return err
+	}
+
+	for _, mvm := range res.Microvm {
+		if err := stream.Send(&v1alpha1.ListMessage{Microvm: mvm}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *fakeServer) DeleteMicroVM(_ context.Context, req *v1alpha1.DeleteMicroVMRequest) (*emptypb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if mvm, ok := s.mvms[req.Uid]; ok {
+		mvm.Status.State = types.MicroVMStatus_DELETING
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func clone(mvm *types.MicroVM) *types.MicroVM {
+	return proto.Clone(mvm).(*types.MicroVM) //nolint: forcetypeassert // always is
+}
+
+func Test_Client(t *testing.T) {
+	g := NewWithT(t)
+
+	client := start(t, newFakeServer())
+	ctx := context.Background()
+
+	created, err := client.Create(ctx, spec("ns", "foo"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(created.Spec.GetUid()).To(Equal("uid-0"))
+
+	mvm, err := client.Get(ctx, "uid-0")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvm.Spec.Id).To(Equal("foo"))
+
+	mvms, err := client.List(ctx, "ns", "")
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvms).To(HaveLen(1))
+
+	g.Expect(client.Delete(ctx, "uid-0")).To(Succeed())
+
+	_, err = client.Get(ctx, "nope")
+	g.Expect(sdk.IsNotFound(err)).To(BeTrue())
+}
+
+func Test_ListStream(t *testing.T) {
+	g := NewWithT(t)
+
+	client := start(t, newFakeServer())
+	ctx := context.Background()
+
+	for _, name := range []string{"foo", "bar", "baz"} {
+		_, err := client.Create(ctx, spec("ns", name))
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	_, err := client.Create(ctx, spec("other", "foo"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	names := []string{}
+
+	g.Expect(client.ListStream(ctx, "ns", "", func(mvm *types.MicroVM) error {
+		names = append(names, mvm.Spec.Id)
+
+		return nil
+	})).To(Succeed())
+	g.Expect(names).To(ConsistOf("foo", "bar", "baz"))
+
+	stop := errors.New("stop")
+	calls := 0
+
+	g.Expect(client.ListStream(ctx, "", "foo", func(*types.MicroVM) error {
+		calls++
+
+		return stop
+	})).To(MatchError(stop))
+	g.Expect(calls).To(Equal(1))
+}
+
+func Test_Client_options(t *testing.T) {
+	g := NewWithT(t)
+
+	var calls int32
+
+	interceptor := func(
+		ctx context.Context, method string, req, reply interface{},
+		cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption,
+	) error {
+		atomic.AddInt32(&calls, 1)
+
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+
+	server := newFakeServer()
+	client := start(t, server, sdk.WithToken("secret"), sdk.WithInterceptors(interceptor))
+
+	_, err := client.Create(context.Background(), spec("ns", "foo"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(server.auth).To(Equal([]string{"Basic c2VjcmV0"}))
+	g.Expect(atomic.LoadInt32(&calls)).To(Equal(int32(1)))
+}
+
+func Test_New_invalidAddress(t *testing.T) {
+	g := NewWithT(t)
+
+	_, err := sdk.New("nope")
+	g.Expect(err).To(MatchError(ContainSubstring(`invalid address "nope"`)))
+}
+
+func Test_New_dialTimeout(t *testing.T) {
+	g := NewWithT(t)
+
+	unreachable := grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		<-ctx.Done()
+
+		return nil, ctx.Err()
+	})
+
+	_, err := sdk.New("127.0.0.1:9090", sdk.WithDialTimeout(50*time.Millisecond), sdk.WithDialOptions(unreachable))
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+}
+
+func Test_Resolve(t *testing.T) {
+	g := NewWithT(t)
+
+	client := start(t, newFakeServer())
+	ctx := context.Background()
+
+	for _, name := range []string{"foo", "bar", "bar"} {
+		_, err := client.Create(ctx, spec("ns", name))
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	mvm, err := client.Resolve(ctx, sdk.Ref{Namespace: "ns", Name: "foo"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvm.Spec.GetUid()).To(Equal("uid-0"))
+
+	_, err = client.Resolve(ctx, sdk.Ref{Namespace: "ns", Name: "bar"})
+	g.Expect(err).To(MatchError(sdk.ErrAmbiguous))
+
+	ambiguous := &sdk.AmbiguousError{}
+	g.Expect(errors.As(err, &ambiguous)).To(BeTrue())
+	g.Expect(ambiguous.MicroVMs).To(HaveLen(2))
+
+	_, err = client.Resolve(ctx, sdk.Ref{Namespace: "other", Name: "foo"})
+	g.Expect(err).To(MatchError(sdk.ErrNotFound))
+	g.Expect(sdk.IsNotFound(err)).To(BeTrue())
+
+	mvm, err = client.Resolve(ctx, sdk.Ref{UID: "uid-1"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvm.Spec.Id).To(Equal("bar"))
+}
+
+func Test_Find_byUIDPrefix(t *testing.T) {
+	g := NewWithT(t)
+
+	client := start(t, newFakeServer())
+	ctx := context.Background()
+
+	for _, name := range []string{"foo", "bar"} {
+		_, err := client.Create(ctx, spec("ns", name))
+		g.Expect(err).NotTo(HaveOccurred())
+	}
+
+	// The server does not know it, so it is matched as a prefix ignoring case
+	mvms, err := client.Find(ctx, sdk.Ref{UID: "UID-0"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvms).To(HaveLen(1))
+	g.Expect(mvms[0].Spec.Id).To(Equal("foo"))
+
+	_, err = client.Find(ctx, sdk.Ref{UID: "uid-"})
+	g.Expect(err).To(MatchError(sdk.ErrAmbiguous))
+
+	_, err = client.Find(ctx, sdk.Ref{UID: "uid"})
+	g.Expect(err).To(MatchError(ContainSubstring("uid prefixes must be at least 4 characters")))
+	g.Expect(sdk.IsNotFound(err)).To(BeTrue())
+
+	_, err = client.Find(ctx, sdk.Ref{UID: "uid-9"})
+	g.Expect(sdk.IsNotFound(err)).To(BeTrue())
+}
+
+func Test_CreateAndWait(t *testing.T) {
+	tt := []struct {
+		name   string
+		states []types.MicroVMStatus_MicroVMState
+		state  types.MicroVMStatus_MicroVMState
+		err    error
+	}{
+		{
+			name:   "created",
+			states: []types.MicroVMStatus_MicroVMState{types.MicroVMStatus_PENDING, types.MicroVMStatus_CREATED},
+			state:  types.MicroVMStatus_CREATED,
+		},
+		{
+			name:   "failed",
+			states: []types.MicroVMStatus_MicroVMState{types.MicroVMStatus_PENDING, types.MicroVMStatus_FAILED},
+			state:  types.MicroVMStatus_FAILED,
+			err:    sdk.ErrFailed,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			client := start(t, newFakeServer(tc.states...))
+
+			mvm, err := client.CreateAndWait(context.Background(), spec("ns", "foo"))
+			if tc.err != nil {
+				g.Expect(err).To(MatchError(tc.err))
+			} else {
+				g.Expect(err).NotTo(HaveOccurred())
+			}
+
+			g.Expect(mvm.Status.State).To(Equal(tc.state))
+		})
+	}
+}
+
+func Test_CreateAndWait_timeout(t *testing.T) {
+	g := NewWithT(t)
+
+	client := start(t, newFakeServer())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	mvm, err := client.CreateAndWait(ctx, spec("ns", "foo"))
+	g.Expect(err).To(MatchError(context.DeadlineExceeded))
+	g.Expect(mvm.Status.State).To(Equal(types.MicroVMStatus_PENDING))
+}
+
+func Test_DeleteAndWait(t *testing.T) {
+	g := NewWithT(t)
+
+	server := newFakeServer()
+	client := start(t, server)
+	ctx := context.Background()
+
+	_, err := client.Create(ctx, spec("ns", "foo"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	g.Expect(client.DeleteAndWait(ctx, "uid-0")).To(Succeed())
+	g.Expect(server.mvms).To(BeEmpty())
+}
+
+func Test_AndWait_loadBalancedReads(t *testing.T) {
+	g := NewWithT(t)
+
+	server := newFakeServer(types.MicroVMStatus_CREATED)
+	client := start(t, server)
+	// Reads go to a server which has never heard of the microvm
+	client.SetReads(start(t, newFakeServer()))
+	ctx := context.Background()
+
+	mvm, err := client.CreateAndWait(ctx, spec("ns", "foo"))
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(mvm.Status.State).To(Equal(types.MicroVMStatus_CREATED))
+
+	g.Expect(client.DeleteAndWait(ctx, "uid-0")).To(Succeed())
+	g.Expect(server.mvms).To(BeEmpty())
+}
+
+func Test_Watch(t *testing.T) {
+	g := NewWithT(t)
+
+	client := start(t, newFakeServer(
+		types.MicroVMStatus_PENDING, types.MicroVMStatus_PENDING, types.MicroVMStatus_CREATED,
+	))
+	ctx := context.Background()
+
+	_, err := client.Create(ctx, spec("ns", "foo"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	events := client.Watch(ctx, "uid-0")
+
+	g.Expect((<-events).MicroVM.Status.State).To(Equal(types.MicroVMStatus_PENDING))
+	g.Expect((<-events).MicroVM.Status.State).To(Equal(types.MicroVMStatus_CREATED))
+
+	g.Expect(client.Delete(ctx, "uid-0")).To(Succeed())
+
+	g.Expect((<-events).MicroVM.Status.State).To(Equal(types.MicroVMStatus_DELETING))
+	g.Expect(<-events).To(Equal(sdk.Event{Deleted: true}))
+	g.Eventually(events).Should(BeClosed())
+}
+
+func Test_Watch_cancelled(t *testing.T) {
+	g := NewWithT(t)
+
+	client := start(t, newFakeServer())
+
+	_, err := client.Create(context.Background(), spec("ns", "foo"))
+	g.Expect(err).NotTo(HaveOccurred())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := client.Watch(ctx, "uid-0")
+
+	g.Expect((<-events).MicroVM).NotTo(BeNil())
+
+	cancel()
+	g.Eventually(events).Should(BeClosed())
+}
+
+func Test_Watch_error(t *testing.T) {
+	g := NewWithT(t)
+
+	client := start(t, newFakeServer(), sdk.WithInterceptors(func(
+		context.Context, string, interface{}, interface{}, *grpc.ClientConn, grpc.UnaryInvoker, ...grpc.CallOption,
+	) error {
+		return errors.New("boom")
+	}))
+
+	event := <-client.Watch(context.Background(), "uid-0")
+	g.Expect(event.Err).To(MatchError(ContainSubstring("boom")))
+}
+
+// start serves server over an in-memory connection and returns a client for
+// it, polling quickly.
+func start(t *testing.T, server v1alpha1.MicroVMServer, opts ...sdk.Option) *sdk.Client {
+	g := NewWithT(t)
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	v1alpha1.RegisterMicroVMServer(grpcServer, server)
+
+	go grpcServer.Serve(listener) //nolint: errcheck // test server
+
+	opts = append(opts,
+		sdk.WithPollInterval(time.Millisecond),
+		sdk.WithDialOptions(grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) {
+			return listener.Dial()
+		})),
+	)
+
+	client, err := sdk.New("bufnet:9090", opts...)
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Cleanup(func() {
+		client.Close()
+		grpcServer.Stop()
+	})
+
+	return client
+}
+
+func spec(namespace, name string) *types.MicroVMSpec {
+	return &types.MicroVMSpec{Id: name, Namespace: namespace}
+}
@@ -0,0 +1,146 @@
+package sdk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var (
+	// ErrFailed is returned by CreateAndWait when flintlock fails to start the
+	// Microvm.
+	ErrFailed = errors.New("microvm failed")
+	// ErrDeleted is returned by CreateAndWait when the Microvm is deleted
+	// before it is running.
+	ErrDeleted = errors.New("microvm deleted")
+)
+
+// Event is a change to a watched Microvm.
+type Event struct {
+	// MicroVM is the Microvm as last fetched. It is nil once Deleted.
+	MicroVM *types.MicroVM
+	// Deleted is set on the last event if the Microvm no longer exists.
+	Deleted bool
+	// Err is set on the last event if fetching the Microvm failed.
+	Err error
+}
+
+// Watch fetches the Microvm from the server Create and Delete go to every poll
+// interval (see WithPollInterval) and sends an event with the first result and
+// each time its state changes. The channel is closed after an event which is
+// Deleted or has an Err, or once ctx is done.
+func (c *Client) Watch(ctx context.Context, uid string) <-chan Event {
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		ticker := time.NewTicker(c.pollInterval)
+		defer ticker.Stop()
+
+		var last *types.MicroVM
+
+		for {
+			event, changed := c.poll(ctx, uid, last)
+
+			if changed {
+				select {
+				case events <- event:
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			if event.Deleted || event.Err != nil {
+				return
+			}
+
+			last = event.MicroVM
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return events
+}
+
+// poll fetches the Microvm and reports whether the result is worth an event.
+// It asks the server Create and Delete go to: with load balanced reads another
+// server, which never had the Microvm, would report it deleted.
+func (c *Client) poll(ctx context.Context, uid string, last *types.MicroVM) (Event, bool) {
+	mvm, err := get(ctx, c.writes, uid)
+
+	switch {
+	case ctx.Err() != nil:
+		return Event{}, false
+	case status.Code(err) == codes.NotFound, err == nil && mvm == nil:
+		return Event{Deleted: true}, true
+	case err != nil:
+		return Event{Err: err}, true
+	}
+
+	changed := last == nil || last.GetStatus().GetState() != mvm.GetStatus().GetState()
+
+	return Event{MicroVM: mvm}, changed
+}
+
+// CreateAndWait creates a new Microvm from the spec and waits until flintlock
+// reports it CREATED. If it fails instead ErrFailed is returned, with the
+// Microvm. ctx bounds the whole wait.
+func (c *Client) CreateAndWait(ctx context.Context, spec *types.MicroVMSpec) (*types.MicroVM, error) {
+	mvm, err := c.Create(ctx, spec)
+	if err != nil {
+		return nil, err
+	}
+
+	uid := mvm.GetSpec().GetUid()
+
+	for event := range c.Watch(ctx, uid) {
+		switch {
+		case event.Err != nil:
+			return mvm, event.Err
+		case event.Deleted:
+			return mvm, fmt.Errorf("%w: %s", ErrDeleted, uid)
+		}
+
+		mvm = event.MicroVM
+
+		switch mvm.GetStatus().GetState() { //nolint: exhaustive // still on its way
+		case types.MicroVMStatus_CREATED:
+			return mvm, nil
+		case types.MicroVMStatus_FAILED:
+			return mvm, fmt.Errorf("%w: %s", ErrFailed, uid)
+		}
+	}
+
+	return mvm, ctx.Err()
+}
+
+// DeleteAndWait deletes a Microvm by its uid and waits until flintlock no
+// longer knows it. ctx bounds the whole wait.
+func (c *Client) DeleteAndWait(ctx context.Context, uid string) error {
+	if err := c.Delete(ctx, uid); err != nil {
+		return err
+	}
+
+	for event := range c.Watch(ctx, uid) {
+		if event.Err != nil {
+			return event.Err
+		}
+
+		if event.Deleted {
+			return nil
+		}
+	}
+
+	return ctx.Err()
+}
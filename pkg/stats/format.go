@@ -0,0 +1,139 @@
+package stats
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+const bytesPerMb = 1024 * 1024
+
+// labelValue escapes label values for the Prometheus text format.
+var labelValue = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Table formats the groups as two tables: the totals, then the number of
+// Microvms using each image.
+func Table(by []string, groups []Group) string {
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0) //nolint: gomnd // column padding
+
+	header := make([]string, 0, len(by))
+	for _, key := range by {
+		header = append(header, strings.ToUpper(key))
+	}
+
+	fmt.Fprintln(tw, strings.Join(append(header, "MICROVMS", "VCPUS", "MEMORY(MB)", "VOLUMES"), "\t"))
+
+	for _, group := range groups {
+		fmt.Fprintf(tw, "%s%d\t%d\t%d\t%d\n",
+			keyColumns(by, group), group.MicroVMs, group.Vcpus, group.MemoryMb, group.Volumes)
+	}
+
+	fmt.Fprintln(tw)
+	fmt.Fprintln(tw, strings.Join(append(header, "IMAGE", "MICROVMS"), "\t"))
+
+	for _, group := range groups {
+		for _, image := range sortedImages(group) {
+			fmt.Fprintf(tw, "%s%s\t%d\n", keyColumns(by, group), image, group.Images[image])
+		}
+	}
+
+	tw.Flush()
+
+	return buf.String()
+}
+
+func keyColumns(by []string, group Group) string {
+	cols := ""
+
+	for _, key := range by {
+		val := group.Keys[key]
+		if val == "" {
+			val = "-"
+		}
+
+		cols += val + "\t"
+	}
+
+	return cols
+}
+
+// Prometheus formats the groups as gauges in the Prometheus text exposition
+// format, labelled with the grouped values.
+func Prometheus(by []string, groups []Group) string {
+	buf := &bytes.Buffer{}
+
+	gauge := func(name, help string, value func(Group) int64) {
+		fmt.Fprintf(buf, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+
+		for _, group := range groups {
+			fmt.Fprintf(buf, "%s%s %d\n", name, labels(by, group, ""), value(group))
+		}
+	}
+
+	gauge("hammertime_microvms", "Number of microvms.",
+		func(g Group) int64 { return int64(g.MicroVMs) })
+	gauge("hammertime_vcpus", "Total vcpus committed to microvms.",
+		func(g Group) int64 { return g.Vcpus })
+	gauge("hammertime_memory_bytes", "Total memory committed to microvms.",
+		func(g Group) int64 { return g.MemoryMb * bytesPerMb })
+	gauge("hammertime_volumes", "Number of microvm volumes.",
+		func(g Group) int64 { return int64(g.Volumes) })
+
+	name := "hammertime_image_microvms"
+	fmt.Fprintf(buf, "# HELP %s Number of microvms using an image.\n# TYPE %s gauge\n", name, name)
+
+	for _, group := range groups {
+		for _, image := range sortedImages(group) {
+			fmt.Fprintf(buf, "%s%s %d\n", name, labels(by, group, image), group.Images[image])
+		}
+	}
+
+	return buf.String()
+}
+
+func labels(by []string, group Group, image string) string {
+	pairs := make([]string, 0, len(by)+1)
+
+	for _, key := range by {
+		pairs = append(pairs, fmt.Sprintf(`%s="%s"`, LabelName(key), labelValue.Replace(group.Keys[key])))
+	}
+
+	if image != "" {
+		pairs = append(pairs, fmt.Sprintf(`image="%s"`, labelValue.Replace(image)))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// LabelName returns the Prometheus label name for a group-by key: label:<key>
+// becomes label_<key>, with any characters not allowed in label names
+// replaced by underscores.
+func LabelName(key string) string {
+	name := []byte(strings.Replace(key, LabelPrefix, "label_", 1))
+
+	for i, c := range name {
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9') {
+			name[i] = '_'
+		}
+	}
+
+	return string(name)
+}
+
+func sortedImages(group Group) []string {
+	images := make([]string, 0, len(group.Images))
+	for image := range group.Images {
+		images = append(images, image)
+	}
+
+	sort.Strings(images)
+
+	return images
+}
@@ -0,0 +1,157 @@
+package stats
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+)
+
+const (
+	// ByHost groups Microvms by the flintlock server they run on.
+	ByHost = "host"
+	// ByNamespace groups Microvms by namespace.
+	ByNamespace = "namespace"
+	// ByState groups Microvms by their state, eg. CREATED.
+	ByState = "state"
+	// LabelPrefix groups Microvms by the value of a label, eg. label:team.
+	LabelPrefix = "label:"
+)
+
+// Sample is a Microvm and the host it was listed from.
+type Sample struct {
+	Host    string
+	MicroVM *types.MicroVM
+}
+
+// Group is the totals for the Microvms which share the same grouped values.
+type Group struct {
+	// Keys are the grouped values, by group-by key.
+	Keys map[string]string `json:"keys"`
+	// MicroVMs is the number of Microvms in the group.
+	MicroVMs int `json:"microvms"`
+	// Vcpus is the total vcpus committed to the group.
+	Vcpus int64 `json:"vcpus"`
+	// MemoryMb is the total memory committed to the group.
+	MemoryMb int64 `json:"memory_mb"`
+	// Volumes is the number of root and additional volumes in the group.
+	Volumes int `json:"volumes"`
+	// Images is the number of Microvms using each kernel, initrd and volume
+	// image.
+	Images map[string]int `json:"images"`
+}
+
+// ValidateGroupBy checks that each key is one which Microvms can be grouped
+// by.
+func ValidateGroupBy(keys []string) error {
+	for _, key := range keys {
+		switch {
+		case key == ByHost, key == ByNamespace, key == ByState:
+		case strings.HasPrefix(key, LabelPrefix) && len(key) > len(LabelPrefix):
+		default:
+			return fmt.Errorf("cannot group by %q, expected one of: %s, %s, %s or %s<key>",
+				key, ByHost, ByNamespace, ByState, LabelPrefix)
+		}
+	}
+
+	return nil
+}
+
+// Summarise totals the samples in groups by the given keys, ordered by their
+// grouped values. With no keys there is a single group of everything.
+func Summarise(samples []Sample, by []string) []Group {
+	groups := map[string]*Group{}
+	values := map[string][]string{}
+
+	for _, sample := range samples {
+		keys, vals := groupValues(sample, by)
+		id := strings.Join(vals, "\x00")
+
+		group, ok := groups[id]
+		if !ok {
+			group = &Group{Keys: keys, Images: map[string]int{}}
+			groups[id] = group
+			values[id] = vals
+		}
+
+		add(group, sample.MicroVM.GetSpec())
+	}
+
+	ids := make([]string, 0, len(groups))
+	for id := range groups {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		a, b := values[ids[i]], values[ids[j]]
+		for k := range a {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+
+		return false
+	})
+
+	out := make([]Group, 0, len(ids))
+	for _, id := range ids {
+		out = append(out, *groups[id])
+	}
+
+	return out
+}
+
+func groupValues(sample Sample, by []string) (map[string]string, []string) {
+	spec := sample.MicroVM.GetSpec()
+	keys := make(map[string]string, len(by))
+	vals := make([]string, 0, len(by))
+
+	for _, key := range by {
+		var val string
+
+		switch key {
+		case ByHost:
+			val = sample.Host
+		case ByNamespace:
+			val = spec.GetNamespace()
+		case ByState:
+			val = sample.MicroVM.GetStatus().GetState().String()
+		default:
+			val = spec.GetLabels()[strings.TrimPrefix(key, LabelPrefix)]
+		}
+
+		keys[key] = val
+		vals = append(vals, val)
+	}
+
+	return keys, vals
+}
+
+func add(group *Group, spec *types.MicroVMSpec) {
+	group.MicroVMs++
+	group.Vcpus += int64(spec.GetVcpu())
+	group.MemoryMb += int64(spec.GetMemoryInMb())
+
+	volumes := spec.GetAdditionalVolumes()
+	if spec.GetRootVolume() != nil {
+		volumes = append([]*types.Volume{spec.GetRootVolume()}, volumes...)
+	}
+
+	group.Volumes += len(volumes)
+
+	images := map[string]bool{
+		spec.GetKernel().GetImage(): true,
+		spec.GetInitrd().GetImage(): true,
+	}
+
+	for _, volume := range volumes {
+		images[volume.GetSource().GetContainerSource()] = true
+	}
+
+	for image := range images {
+		if image != "" {
+			group.Images[image]++
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package stats_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+
+	"github.com/warehouse-13/hammertime/pkg/internal/fixtures"
+	"github.com/warehouse-13/hammertime/pkg/stats"
+)
+
+func Test_ValidateGroupBy(t *testing.T) {
+	g := NewWithT(t)
+
+	g.Expect(stats.ValidateGroupBy([]string{"host", "namespace", "state", "label:team"})).To(Succeed())
+	g.Expect(stats.ValidateGroupBy(nil)).To(Succeed())
+	g.Expect(stats.ValidateGroupBy([]string{"label:"})).To(MatchError(ContainSubstring(`cannot group by "label:"`)))
+	g.Expect(stats.ValidateGroupBy([]string{"zone"})).To(MatchError(ContainSubstring(`cannot group by "zone"`)))
+}
+
+func Test_Summarise(t *testing.T) {
+	g := NewWithT(t)
+
+	samples := []stats.Sample{
+		{Host: "b:9090", MicroVM: microvm("ns0", "team-a", 2, 1024, "kernel:1", "os:1", "data:1")},
+		{Host: "a:9090", MicroVM: microvm("ns1", "team-b", 4, 2048, "kernel:1", "os:2")},
+		{Host: "a:9090", MicroVM: microvm("ns1", "", 1, 512, "kernel:1", "os:1")},
+		{Host: "a:9090", MicroVM: microvm("ns0", "team-a", 2, 1024, "kernel:2", "os:1", "os:1")},
+	}
+
+	groups := stats.Summarise(samples, []string{stats.ByHost, stats.ByNamespace})
+	g.Expect(groups).To(Equal([]stats.Group{
+		{
+			Keys:     map[string]string{"host": "a:9090", "namespace": "ns0"},
+			MicroVMs: 1, Vcpus: 2, MemoryMb: 1024, Volumes: 2,
+			Images: map[string]int{"kernel:2": 1, "os:1": 1},
+		},
+		{
+			Keys:     map[string]string{"host": "a:9090", "namespace": "ns1"},
+			MicroVMs: 2, Vcpus: 5, MemoryMb: 2560, Volumes: 2,
+			Images: map[string]int{"kernel:1": 2, "os:1": 1, "os:2": 1},
+		},
+		{
+			Keys:     map[string]string{"host": "b:9090", "namespace": "ns0"},
+			MicroVMs: 1, Vcpus: 2, MemoryMb: 1024, Volumes: 2,
+			Images: map[string]int{"kernel:1": 1, "os:1": 1, "data:1": 1},
+		},
+	}))
+
+	groups = stats.Summarise(samples, []string{"label:team"})
+	g.Expect(groups).To(HaveLen(3))
+	g.Expect(groups[0].Keys).To(Equal(map[string]string{"label:team": ""}))
+	g.Expect(groups[1].Keys).To(Equal(map[string]string{"label:team": "team-a"}))
+	g.Expect(groups[1].MicroVMs).To(Equal(2))
+
+	groups = stats.Summarise(samples, nil)
+	g.Expect(groups).To(HaveLen(1))
+	g.Expect(groups[0].MicroVMs).To(Equal(4))
+	g.Expect(groups[0].Vcpus).To(Equal(int64(9)))
+	g.Expect(groups[0].Volumes).To(Equal(6))
+	g.Expect(groups[0].Images).To(Equal(map[string]int{"kernel:1": 3, "kernel:2": 1, "os:1": 3, "os:2": 1, "data:1": 1}))
+
+	g.Expect(stats.Summarise(nil, []string{stats.ByHost})).To(BeEmpty())
+}
+
+func Test_Prometheus(t *testing.T) {
+	g := NewWithT(t)
+
+	groups := []stats.Group{{
+		Keys:     map[string]string{"host": "a:9090", "label:app.kubernetes.io/name": `we"ird`},
+		MicroVMs: 2, Vcpus: 4, MemoryMb: 1024, Volumes: 3,
+		Images: map[string]int{"os:1": 2},
+	}}
+
+	out := stats.Prometheus([]string{"host", "label:app.kubernetes.io/name"}, groups)
+	g.Expect(out).To(ContainSubstring("# TYPE hammertime_microvms gauge\n"))
+	g.Expect(out).To(ContainSubstring(
+		`hammertime_microvms{host="a:9090",label_app_kubernetes_io_name="we\"ird"} 2` + "\n"))
+	g.Expect(out).To(ContainSubstring(
+		`hammertime_memory_bytes{host="a:9090",label_app_kubernetes_io_name="we\"ird"} 1073741824` + "\n"))
+	g.Expect(out).To(ContainSubstring(
+		`hammertime_image_microvms{host="a:9090",label_app_kubernetes_io_name="we\"ird",image="os:1"} 2` + "\n"))
+
+	g.Expect(stats.Prometheus(nil, []stats.Group{{MicroVMs: 1}})).To(ContainSubstring("hammertime_microvms 1\n"))
+}
+
+func Test_Table(t *testing.T) {
+	g := NewWithT(t)
+
+	groups := []stats.Group{{
+		Keys:     map[string]string{"namespace": ""},
+		MicroVMs: 2, Vcpus: 4, MemoryMb: 1024, Volumes: 3,
+		Images: map[string]int{"os:1": 2},
+	}}
+
+	g.Expect(stats.Table([]string{"namespace"}, groups)).To(Equal(
+		"NAMESPACE  MICROVMS  VCPUS  MEMORY(MB)  VOLUMES\n" +
+			"-          2         4      1024        3\n" +
+			"\n" +
+			"NAMESPACE  IMAGE  MICROVMS\n" +
+			"-          os:1   2\n",
+	))
+}
+
+func microvm(namespace, team string, vcpu, memory int32, kernel, root string, additional ...string) *types.MicroVM {
+	opts := []fixtures.Option{fixtures.WithResources(vcpu, memory), fixtures.WithImages(kernel, root, additional...)}
+	if team != "" {
+		opts = append(opts, fixtures.WithLabels(map[string]string{"team": team}))
+	}
+
+	return fixtures.MicroVM(namespace, "mvm", "", opts...)
+}
@@ -0,0 +1,86 @@
+package tui
+
+import "unicode/utf8"
+
+// Key is a single key press. Printable keys are the character itself, others
+// are one of the named keys below.
+type Key string
+
+const (
+	KeyUp        Key = "up"
+	KeyDown      Key = "down"
+	KeyPageUp    Key = "pgup"
+	KeyPageDown  Key = "pgdown"
+	KeyEnter     Key = "enter"
+	KeyEsc       Key = "esc"
+	KeyBackspace Key = "backspace"
+	KeyCtrlC     Key = "ctrl+c"
+)
+
+var escapes = map[string]Key{
+	"\x1b[A":  KeyUp,
+	"\x1b[B":  KeyDown,
+	"\x1bOA":  KeyUp,
+	"\x1bOB":  KeyDown,
+	"\x1b[5~": KeyPageUp,
+	"\x1b[6~": KeyPageDown,
+}
+
+// ParseKeys splits raw terminal input into key presses. Unknown escape
+// sequences are dropped.
+func ParseKeys(in []byte) []Key {
+	keys := []Key{}
+
+	for len(in) > 0 {
+		switch in[0] {
+		case '\r', '\n':
+			keys = append(keys, KeyEnter)
+		case 0x7f, '\b':
+			keys = append(keys, KeyBackspace)
+		case 0x03:
+			keys = append(keys, KeyCtrlC)
+		case 0x1b:
+			key, n := parseEscape(in)
+			if key != "" {
+				keys = append(keys, key)
+			}
+
+			in = in[n:]
+
+			continue
+		default:
+			r, n := utf8.DecodeRune(in)
+			if r >= ' ' && r != utf8.RuneError {
+				keys = append(keys, Key(string(r)))
+			}
+
+			in = in[n:]
+
+			continue
+		}
+
+		in = in[1:]
+	}
+
+	return keys
+}
+
+// parseEscape returns the key for the escape sequence at the start of in, and
+// how many bytes it used. A lone escape is KeyEsc.
+func parseEscape(in []byte) (Key, int) {
+	if len(in) == 1 || (in[1] != '[' && in[1] != 'O') {
+		return KeyEsc, 1
+	}
+
+	// CSI sequences end with a byte in the range @ to ~.
+	end := 2
+	for end < len(in) && (in[end] < '@' || in[end] > '~') {
+		end++
+	}
+
+	if end == len(in) {
+		return "", len(in)
+	}
+
+	return escapes[string(in[:end+1])], end + 1
+}
@@ -0,0 +1,19 @@
+package tui_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/tui"
+)
+
+func Test_ParseKeys(t *testing.T) {
+	g := NewWithT(t)
+
+	keys := tui.ParseKeys([]byte("j\x1b[A\x1b[B\x1b[5~\x1b[6~\r\x7f\x03é\x1b[1;5C\x1b"))
+	g.Expect(keys).To(Equal([]tui.Key{
+		"j", tui.KeyUp, tui.KeyDown, tui.KeyPageUp, tui.KeyPageDown,
+		tui.KeyEnter, tui.KeyBackspace, tui.KeyCtrlC, "é", tui.KeyEsc,
+	}))
+}
@@ -0,0 +1,663 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/warehouse-13/hammertime/pkg/client"
+	"github.com/warehouse-13/hammertime/pkg/microvm"
+	"github.com/warehouse-13/hammertime/pkg/resolver"
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+const (
+	helpText = "↑/↓ move  enter detail  / filter  d delete  c clone  y copy uid  r refresh  q quit"
+
+	cloneSuffix = "-clone"
+)
+
+type mode int
+
+const (
+	modeList mode = iota
+	modeFilter
+	modeDelete
+	modeClone
+)
+
+// Host is a flintlock server shown in the ui.
+type Host struct {
+	Address string
+	Client  client.FlintlockClient
+}
+
+// streamLister is implemented by clients which can list Microvms over the
+// ListMicroVMsStream RPC, like client.Client.
+type streamLister interface {
+	ListStream(name, ns string) (*v1alpha1.ListMicroVMsResponse, error)
+}
+
+// Item is a Microvm and the host it runs on.
+type Item struct {
+	Host    string
+	MicroVM *types.MicroVM
+}
+
+// Snapshot is the result of listing the Microvms on every host.
+type Snapshot struct {
+	Items []Item
+	// Errors are the hosts which could not be listed, with the reason.
+	Errors []string
+
+	// mutations is how many deletes or clones had completed when the listing
+	// started.
+	mutations int64
+}
+
+// Action is work started by a key press, eg. a delete. It calls flintlock so
+// must be run in the background, and its Result passed to Finish.
+type Action func() Result
+
+// Result is the outcome of an Action.
+type Result struct {
+	// Status replaces the status line, if set.
+	Status string
+	// Snapshot is the Microvms listed after the action, if it got that far.
+	Snapshot *Snapshot
+}
+
+// Model is the state of the ui. Key presses are fed to Update and the screen
+// is drawn from View, so it can be driven without a terminal.
+type Model struct {
+	hosts     []Host
+	clipboard io.Writer
+	// protectionLabel is the label which protects a Microvm from being
+	// deleted, if set.
+	protectionLabel string
+
+	snapshot Snapshot
+	filter   string
+	cursor   int
+	detail   bool
+	scroll   int
+	mode     mode
+	// target is the Microvm being deleted or cloned, which stays the same even
+	// if a refresh moves the cursor.
+	target *Item
+	input  string
+	status string
+
+	// actions are the Actions started since they were last taken.
+	actions []Action
+	// mutations counts the deletes and clones which have completed. It is
+	// updated by Actions in the background, so only use it atomically.
+	mutations int64
+}
+
+// New returns a Model for the hosts. Copied UIDs are written to clipboard as
+// an OSC 52 escape sequence, which most terminals put on the system clipboard.
+// Microvms carrying the protectionLabel (if set) cannot be deleted.
+func New(hosts []Host, clipboard io.Writer, protectionLabel string) *Model {
+	return &Model{
+		hosts:           hosts,
+		clipboard:       clipboard,
+		protectionLabel: protectionLabel,
+	}
+}
+
+// Fetch lists the Microvms on every host, over the streaming RPC where the
+// client and server support it. It does not change the Model, so it can run in
+// the background while keys are being handled.
+func (m *Model) Fetch() Snapshot {
+	mutations := atomic.LoadInt64(&m.mutations)
+
+	var (
+		items = make([][]Item, len(m.hosts))
+		errs  = make([]error, len(m.hosts))
+		wg    sync.WaitGroup
+	)
+
+	for i, host := range m.hosts {
+		wg.Add(1)
+
+		go func(i int, host Host) {
+			defer wg.Done()
+
+			res, err := list(host.Client)
+			if err != nil {
+				errs[i] = err
+
+				return
+			}
+
+			for _, mvm := range res.Microvm {
+				items[i] = append(items[i], Item{Host: host.Address, MicroVM: mvm})
+			}
+		}(i, host)
+	}
+
+	wg.Wait()
+
+	snapshot := Snapshot{Items: []Item{}, mutations: mutations}
+
+	for i, host := range m.hosts {
+		if errs[i] != nil {
+			snapshot.Errors = append(snapshot.Errors, fmt.Sprintf("%s: %s", host.Address, errs[i]))
+		}
+
+		snapshot.Items = append(snapshot.Items, items[i]...)
+	}
+
+	sort.SliceStable(snapshot.Items, func(i, j int) bool {
+		a, b := snapshot.Items[i].MicroVM.Spec, snapshot.Items[j].MicroVM.Spec
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+
+		return a.Id < b.Id
+	})
+
+	return snapshot
+}
+
+// Apply replaces the listed Microvms, keeping the same one selected if it is
+// still there. Snapshots fetched before the last delete or clone completed are
+// discarded, so a slow refresh cannot bring back a deleted Microvm.
+func (m *Model) Apply(snapshot Snapshot) {
+	if snapshot.mutations < atomic.LoadInt64(&m.mutations) {
+		return
+	}
+
+	selected := m.Selected()
+
+	m.snapshot = snapshot
+	m.cursor = 0
+
+	if selected == nil {
+		return
+	}
+
+	for i, item := range m.Visible() {
+		if item.Host == selected.Host && item.MicroVM.Spec.GetUid() == selected.MicroVM.Spec.GetUid() {
+			m.cursor = i
+
+			return
+		}
+	}
+
+	m.detail = false
+}
+
+// Refresh fetches and applies the Microvms.
+func (m *Model) Refresh() {
+	m.Apply(m.Fetch())
+}
+
+// Actions returns the Actions started by key presses since it was last called.
+func (m *Model) Actions() []Action {
+	actions := m.actions
+	m.actions = nil
+
+	return actions
+}
+
+// Finish shows the result of an Action.
+func (m *Model) Finish(result Result) {
+	if utils.IsSet(result.Status) {
+		m.status = result.Status
+	}
+
+	if result.Snapshot != nil {
+		m.Apply(*result.Snapshot)
+	}
+}
+
+func (m *Model) start(status string, action Action) {
+	m.status = status
+	m.actions = append(m.actions, action)
+}
+
+// fetch returns the snapshot to put in a Result.
+func (m *Model) fetch() *Snapshot {
+	snapshot := m.Fetch()
+
+	return &snapshot
+}
+
+// Visible returns the Microvms which match the filter.
+func (m *Model) Visible() []Item {
+	visible := []Item{}
+
+	for _, item := range m.snapshot.Items {
+		if matches(item, m.filter) {
+			visible = append(visible, item)
+		}
+	}
+
+	return visible
+}
+
+// Selected returns the Microvm under the cursor, or nil if there are none.
+func (m *Model) Selected() *Item {
+	visible := m.Visible()
+	if m.cursor >= len(visible) {
+		return nil
+	}
+
+	return &visible[m.cursor]
+}
+
+// Status returns the message shown at the bottom of the screen, eg. the
+// outcome of the last action.
+func (m *Model) Status() string {
+	return m.status
+}
+
+// Update handles a key press. It returns true when the user has quit.
+func (m *Model) Update(key Key) bool {
+	if key == KeyCtrlC {
+		return true
+	}
+
+	switch m.mode {
+	case modeFilter:
+		m.updateFilter(key)
+	case modeDelete:
+		m.updateDelete(key)
+	case modeClone:
+		m.updateClone(key)
+	case modeList:
+		return m.updateList(key)
+	}
+
+	return false
+}
+
+func (m *Model) updateList(key Key) bool {
+	selected := m.Selected()
+
+	switch key {
+	case "q":
+		return true
+	case KeyUp, "k":
+		m.move(-1)
+	case KeyDown, "j":
+		m.move(1)
+	case KeyPageUp:
+		m.scroll = max(m.scroll-10, 0) //nolint: gomnd // half a page or so
+	case KeyPageDown:
+		m.scroll += 10
+	case KeyEnter:
+		m.detail = !m.detail && selected != nil
+		m.scroll = 0
+	case KeyEsc:
+		m.detail = false
+	case "/":
+		m.mode = modeFilter
+	case "r":
+		m.start("", func() Result {
+			return Result{Snapshot: m.fetch()}
+		})
+	case "d":
+		switch {
+		case selected == nil:
+		case m.protected(selected):
+			m.status = fmt.Sprintf("Refusing to delete protected %s (labelled %s)", describe(selected), m.protectionLabel)
+		default:
+			m.mode = modeDelete
+			m.target = selected
+		}
+	case "c":
+		if selected != nil {
+			m.mode = modeClone
+			m.target = selected
+			m.input = selected.MicroVM.Spec.Id + cloneSuffix
+		}
+	case "y":
+		if selected != nil {
+			m.copy(selected.MicroVM.Spec.GetUid())
+		}
+	}
+
+	return false
+}
+
+func (m *Model) updateFilter(key Key) {
+	switch key {
+	case KeyEnter:
+		m.mode = modeList
+	case KeyEsc:
+		m.filter = ""
+		m.mode = modeList
+	default:
+		m.filter = edit(m.filter, key)
+	}
+
+	m.cursor = 0
+	m.detail = false
+}
+
+func (m *Model) updateDelete(key Key) {
+	m.mode = modeList
+
+	if key != "y" && key != "Y" {
+		m.status = "Delete cancelled"
+
+		return
+	}
+
+	item, client := m.target, m.client(m.target.Host)
+
+	m.start(fmt.Sprintf("Deleting %s...", describe(item)), func() Result {
+		if _, err := client.Delete(item.MicroVM.Spec.GetUid()); err != nil {
+			return Result{Status: fmt.Sprintf("Error: deleting %s: %s", describe(item), err)}
+		}
+
+		atomic.AddInt64(&m.mutations, 1)
+
+		return Result{Status: fmt.Sprintf("Deleted %s", describe(item)), Snapshot: m.fetch()}
+	})
+}
+
+func (m *Model) updateClone(key Key) {
+	switch key {
+	case KeyEsc:
+		m.mode = modeList
+		m.status = "Clone cancelled"
+	case KeyEnter:
+		m.mode = modeList
+		m.clone(m.target, m.input)
+	default:
+		m.input = edit(m.input, key)
+	}
+}
+
+// clone creates a copy of the item on the same host, under the new name.
+func (m *Model) clone(item *Item, name string) {
+	if !utils.IsSet(name) {
+		m.status = "Error: the clone needs a name"
+
+		return
+	}
+
+	spec, err := microvm.Clone(item.MicroVM.Spec, name)
+	if err != nil {
+		m.status = fmt.Sprintf("Error: cloning %s: %s", describe(item), err)
+
+		return
+	}
+
+	client := m.client(item.Host)
+
+	m.start(fmt.Sprintf("Cloning %s as %s...", describe(item), name), func() Result {
+		res, err := client.Create(spec)
+		if err != nil {
+			return Result{Status: fmt.Sprintf("Error: cloning %s: %s", describe(item), err)}
+		}
+
+		atomic.AddInt64(&m.mutations, 1)
+
+		return Result{
+			Status:   fmt.Sprintf("Created %s/%s %s", spec.Namespace, spec.Id, res.GetMicrovm().GetSpec().GetUid()),
+			Snapshot: m.fetch(),
+		}
+	})
+}
+
+func (m *Model) protected(item *Item) bool {
+	return utils.IsSet(m.protectionLabel) && utils.HasLabel(item.MicroVM.Spec.Labels, m.protectionLabel)
+}
+
+func (m *Model) copy(uid string) {
+	fmt.Fprintf(m.clipboard, "\x1b]52;c;%s\a", base64.StdEncoding.EncodeToString([]byte(uid)))
+
+	m.status = fmt.Sprintf("Copied %s to the clipboard", uid)
+}
+
+func (m *Model) move(by int) {
+	m.cursor = min(max(m.cursor+by, 0), max(len(m.Visible())-1, 0))
+	m.scroll = 0
+}
+
+// list lists every Microvm with the client, falling back to the List RPC for
+// servers which do not implement the stream.
+func list(c client.FlintlockClient) (*v1alpha1.ListMicroVMsResponse, error) {
+	if streamer, ok := c.(streamLister); ok {
+		res, err := streamer.ListStream("", "")
+		if status.Code(err) != codes.Unimplemented {
+			return res, err
+		}
+	}
+
+	return c.List("", "")
+}
+
+func (m *Model) client(address string) client.FlintlockClient {
+	for _, host := range m.hosts {
+		if host.Address == address {
+			return host.Client
+		}
+	}
+
+	return nil
+}
+
+// View draws the screen, width columns by height rows: the list of Microvms,
+// the detail pane if it is open, and a status line.
+func (m *Model) View(width, height int) string {
+	visible := m.Visible()
+
+	lines := []string{m.title(len(visible))}
+
+	for _, err := range m.snapshot.Errors {
+		lines = append(lines, "! "+err)
+	}
+
+	// Leave a line for the footer
+	listHeight := height - len(lines) - 1
+	if m.detail {
+		listHeight /= 2
+	}
+
+	lines = append(lines, m.table(visible, listHeight)...)
+
+	if m.detail {
+		detail := m.detailLines()
+		room := height - len(lines) - 2 //nolint: gomnd // the separator and the footer
+		m.scroll = min(m.scroll, max(len(detail)-room, 0))
+
+		lines = append(lines, strings.Repeat("─", width))
+		lines = append(lines, detail[m.scroll:min(m.scroll+max(room, 0), len(detail))]...)
+	}
+
+	for len(lines) < height-1 {
+		lines = append(lines, "")
+	}
+
+	lines = append(lines, m.footer())
+
+	for i, line := range lines {
+		lines[i] = truncate(line, width)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+func (m *Model) title(visible int) string {
+	title := fmt.Sprintf("hammertime: %d microvms on %d hosts", len(m.snapshot.Items), len(m.hosts))
+	if utils.IsSet(m.filter) {
+		title += fmt.Sprintf(", %d matching %q", visible, m.filter)
+	}
+
+	return title
+}
+
+// table returns the header and the rows of the list which fit in height,
+// scrolled to keep the cursor in view.
+func (m *Model) table(visible []Item, height int) []string {
+	rows := max(height-1, 1)
+	start := max(m.cursor-rows+1, 0)
+	end := min(start+rows, len(visible))
+
+	buf := &bytes.Buffer{}
+	tw := tabwriter.NewWriter(buf, 0, 0, 2, ' ', 0) //nolint: gomnd // column padding
+
+	header := "  NAMESPACE\tNAME\tUID\tSTATE"
+	if len(m.hosts) > 1 {
+		header = "  HOST\tNAMESPACE\tNAME\tUID\tSTATE"
+	}
+
+	fmt.Fprintln(tw, header)
+
+	for i := start; i < end; i++ {
+		spec := visible[i].MicroVM.Spec
+		state := visible[i].MicroVM.GetStatus().GetState().String()
+
+		cursor := "  "
+		if i == m.cursor {
+			cursor = "> "
+		}
+
+		if len(m.hosts) > 1 {
+			fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\t%s\n", cursor, visible[i].Host, spec.Namespace, spec.Id, spec.GetUid(), state)
+
+			continue
+		}
+
+		fmt.Fprintf(tw, "%s%s\t%s\t%s\t%s\n", cursor, spec.Namespace, spec.Id, spec.GetUid(), state)
+	}
+
+	tw.Flush()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(visible) == 0 {
+		lines = append(lines, "  no microvms")
+	}
+
+	return lines
+}
+
+// detailLines returns the decoded spec and status of the selected Microvm.
+func (m *Model) detailLines() []string {
+	item := m.Selected()
+	if item == nil {
+		return nil
+	}
+
+	spec, err := microvm.DecodeSpec(item.MicroVM.Spec, false)
+	if err != nil {
+		return []string{"Error: " + err.Error()}
+	}
+
+	out, err := json.MarshalIndent(map[string]interface{}{
+		"host":   item.Host,
+		"spec":   spec,
+		"status": item.MicroVM.Status,
+	}, "", "  ")
+	if err != nil {
+		return []string{"Error: " + err.Error()}
+	}
+
+	return strings.Split(string(out), "\n")
+}
+
+func (m *Model) footer() string {
+	switch m.mode {
+	case modeFilter:
+		return "/" + m.filter + "█"
+	case modeDelete:
+		return fmt.Sprintf("Delete %s? [y/N]", describe(m.target))
+	case modeClone:
+		return "Clone as: " + m.input + "█"
+	case modeList:
+	}
+
+	if utils.IsSet(m.status) {
+		return m.status
+	}
+
+	return helpText
+}
+
+// matches reports whether the item matches every term in the filter. Terms
+// are `ns:<namespace>`, `label:<key>[=<value>]`, or text to find in the host,
+// namespace, name or uid.
+func matches(item Item, filter string) bool {
+	spec := item.MicroVM.Spec
+
+	for _, term := range strings.Fields(filter) {
+		var ok bool
+
+		switch {
+		case strings.HasPrefix(term, "ns:"):
+			ok = spec.Namespace == strings.TrimPrefix(term, "ns:")
+		case strings.HasPrefix(term, "label:"):
+			ok = utils.HasLabel(spec.Labels, strings.TrimPrefix(term, "label:"))
+		default:
+			ok = strings.Contains(strings.Join([]string{item.Host, spec.Namespace, spec.Id, spec.GetUid()}, " "), term)
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// edit applies a key to a line of text input.
+func edit(text string, key Key) string {
+	if key == KeyBackspace {
+		_, size := utf8.DecodeLastRuneInString(text)
+
+		return text[:len(text)-size]
+	}
+
+	if utf8.RuneCountInString(string(key)) == 1 {
+		return text + string(key)
+	}
+
+	return text
+}
+
+func truncate(line string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(line) <= width {
+		return line
+	}
+
+	return string([]rune(line)[:width])
+}
+
+func describe(item *Item) string {
+	return resolver.Describe(item.MicroVM)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
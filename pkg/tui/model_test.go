@@ -0,0 +1,312 @@
+package tui_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
+	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+
+	"github.com/warehouse-13/hammertime/pkg/client/fakeclient"
+	"github.com/warehouse-13/hammertime/pkg/internal/fixtures"
+	"github.com/warehouse-13/hammertime/pkg/tui"
+)
+
+func Test_Model_list(t *testing.T) {
+	g := NewWithT(t)
+
+	first, second := new(fakeclient.FakeFlintlockClient), new(fakeclient.FakeFlintlockClient)
+	first.ListReturns(fixtures.ListResponse(mvm("ns1", "b", "uid-b", nil), mvm("ns1", "a", "uid-a", nil)), nil)
+	second.ListReturns(nil, errors.New("boom"))
+
+	model := tui.New([]tui.Host{{"host1:9090", first}, {"host2:9090", second}}, &bytes.Buffer{}, "")
+	model.Refresh()
+
+	g.Expect(first.ListArgsForCall(0)).To(BeEmpty())
+	g.Expect(model.Selected().MicroVM.Spec.Id).To(Equal("a"))
+
+	view := model.View(120, 10)
+	g.Expect(view).To(ContainSubstring("hammertime: 2 microvms on 2 hosts"))
+	g.Expect(view).To(ContainSubstring("! host2:9090: boom"))
+	g.Expect(view).To(MatchRegexp(`> host1:9090\s+ns1\s+a\s+uid-a\s+CREATED`))
+	g.Expect(strings.Split(view, "\n")).To(HaveLen(10))
+
+	// The selection follows the Microvm when the list changes
+	model.Update(tui.KeyDown)
+	g.Expect(model.Selected().MicroVM.Spec.Id).To(Equal("b"))
+
+	first.ListReturns(fixtures.ListResponse(mvm("ns1", "b", "uid-b", nil)), nil)
+	model.Update("r")
+	g.Expect(model.Visible()).To(HaveLen(2))
+
+	run(model)
+	g.Expect(model.Visible()).To(HaveLen(1))
+	g.Expect(model.Selected().MicroVM.Spec.Id).To(Equal("b"))
+
+	g.Expect(model.Update("q")).To(BeTrue())
+}
+
+// streamClient is a client which lists over the stream RPC.
+type streamClient struct {
+	*fakeclient.FakeFlintlockClient
+
+	stream func() (*v1alpha1.ListMicroVMsResponse, error)
+}
+
+func (c streamClient) ListStream(string, string) (*v1alpha1.ListMicroVMsResponse, error) {
+	return c.stream()
+}
+
+func Test_Model_listStream(t *testing.T) {
+	g := NewWithT(t)
+
+	fake := new(fakeclient.FakeFlintlockClient)
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "a", "uid-a", nil)), nil)
+
+	client := streamClient{FakeFlintlockClient: fake, stream: func() (*v1alpha1.ListMicroVMsResponse, error) {
+		return fixtures.ListResponse(mvm("ns1", "b", "uid-b", nil)), nil
+	}}
+
+	model := tui.New([]tui.Host{{"host1:9090", client}}, &bytes.Buffer{}, "")
+	model.Refresh()
+	g.Expect(names(model.Visible())).To(Equal([]string{"b"}))
+	g.Expect(fake.ListCallCount()).To(BeZero())
+
+	// Servers without the stream are listed as before
+	client.stream = func() (*v1alpha1.ListMicroVMsResponse, error) {
+		return nil, status.Error(codes.Unimplemented, "unknown method")
+	}
+
+	model = tui.New([]tui.Host{{"host1:9090", client}}, &bytes.Buffer{}, "")
+	model.Refresh()
+	g.Expect(names(model.Visible())).To(Equal([]string{"a"}))
+	g.Expect(fake.ListCallCount()).To(Equal(1))
+}
+
+func Test_Model_filter(t *testing.T) {
+	g := NewWithT(t)
+
+	fake := new(fakeclient.FakeFlintlockClient)
+	fake.ListReturns(fixtures.ListResponse(
+		mvm("ns1", "a", "uid-a", map[string]string{"role": "web"}),
+		mvm("ns1", "b", "uid-b", map[string]string{"role": "db"}),
+		mvm("ns2", "c", "uid-c", map[string]string{"role": "web"}),
+	), nil)
+
+	model := tui.New([]tui.Host{{"host1:9090", fake}}, &bytes.Buffer{}, "")
+	model.Refresh()
+
+	typeKeys(model, "/ns:ns1 label:role=web")
+	g.Expect(names(model.Visible())).To(Equal([]string{"a"}))
+	g.Expect(model.View(80, 10)).To(ContainSubstring("/ns:ns1 label:role=web"))
+
+	model.Update(tui.KeyEnter)
+	g.Expect(model.View(80, 10)).To(ContainSubstring(`1 matching "ns:ns1 label:role=web"`))
+
+	typeKeys(model, "/")
+	for range "ns:ns1 label:role=web" {
+		model.Update(tui.KeyBackspace)
+	}
+
+	typeKeys(model, "label:role=web")
+	model.Update(tui.KeyEnter)
+	g.Expect(names(model.Visible())).To(Equal([]string{"a", "c"}))
+
+	typeKeys(model, "/")
+	model.Update(tui.KeyEsc)
+	g.Expect(model.Visible()).To(HaveLen(3))
+}
+
+func Test_Model_detail(t *testing.T) {
+	g := NewWithT(t)
+
+	fake := new(fakeclient.FakeFlintlockClient)
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "a", "uid-a", nil)), nil)
+
+	model := tui.New([]tui.Host{{"host1:9090", fake}}, &bytes.Buffer{}, "")
+	model.Refresh()
+
+	g.Expect(model.View(80, 40)).NotTo(ContainSubstring(`"spec"`))
+
+	model.Update(tui.KeyEnter)
+
+	view := model.View(80, 40)
+	g.Expect(view).To(ContainSubstring(`"host": "host1:9090"`))
+	g.Expect(view).To(ContainSubstring(`"local_hostname": "a"`))
+	g.Expect(view).To(ContainSubstring(`"state": 1`))
+
+	model.Update(tui.KeyEsc)
+	g.Expect(model.View(80, 40)).NotTo(ContainSubstring(`"spec"`))
+}
+
+func Test_Model_delete(t *testing.T) {
+	g := NewWithT(t)
+
+	fake := new(fakeclient.FakeFlintlockClient)
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "a", "uid-a", nil)), nil)
+	fake.DeleteReturns(&emptypb.Empty{}, nil)
+
+	model := tui.New([]tui.Host{{"host1:9090", fake}}, &bytes.Buffer{}, "")
+	model.Refresh()
+
+	typeKeys(model, "dn")
+	g.Expect(fake.DeleteCallCount()).To(BeZero())
+	g.Expect(model.Status()).To(Equal("Delete cancelled"))
+
+	typeKeys(model, "d")
+	g.Expect(model.View(80, 10)).To(ContainSubstring("Delete ns1/a uid-a? [y/N]"))
+
+	fake.ListReturns(fixtures.ListResponse(), nil)
+	typeKeys(model, "y")
+
+	// The delete is left to run in the background
+	g.Expect(fake.DeleteCallCount()).To(BeZero())
+	g.Expect(model.Status()).To(Equal("Deleting ns1/a uid-a..."))
+
+	run(model)
+	g.Expect(fake.DeleteCallCount()).To(Equal(1))
+	g.Expect(fake.DeleteArgsForCall(0)).To(Equal("uid-a"))
+	g.Expect(model.Status()).To(Equal("Deleted ns1/a uid-a"))
+	g.Expect(model.Visible()).To(BeEmpty())
+
+	fake.DeleteReturns(nil, errors.New("boom"))
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "a", "uid-a", nil)), nil)
+	model.Refresh()
+	typeKeys(model, "dy")
+	run(model)
+	g.Expect(model.Status()).To(Equal("Error: deleting ns1/a uid-a: boom"))
+}
+
+func Test_Model_delete_protected(t *testing.T) {
+	g := NewWithT(t)
+
+	fake := new(fakeclient.FakeFlintlockClient)
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "a", "uid-a", map[string]string{"protected": "true"})), nil)
+	fake.DeleteReturns(&emptypb.Empty{}, nil)
+
+	model := tui.New([]tui.Host{{"host1:9090", fake}}, &bytes.Buffer{}, "protected=true")
+	model.Refresh()
+
+	typeKeys(model, "d")
+	run(model)
+	g.Expect(fake.DeleteCallCount()).To(BeZero())
+	g.Expect(model.Status()).To(Equal("Refusing to delete protected ns1/a uid-a (labelled protected=true)"))
+	g.Expect(names(model.Visible())).To(Equal([]string{"a"}))
+}
+
+func Test_Model_delete_staleSnapshot(t *testing.T) {
+	g := NewWithT(t)
+
+	fake := new(fakeclient.FakeFlintlockClient)
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "a", "uid-a", nil)), nil)
+	fake.DeleteReturns(&emptypb.Empty{}, nil)
+
+	model := tui.New([]tui.Host{{"host1:9090", fake}}, &bytes.Buffer{}, "")
+	model.Refresh()
+
+	// A refresh which was under way when the Microvm was deleted
+	stale := model.Fetch()
+
+	fake.ListReturns(fixtures.ListResponse(), nil)
+	typeKeys(model, "dy")
+	run(model)
+	g.Expect(model.Visible()).To(BeEmpty())
+
+	model.Apply(stale)
+	g.Expect(model.Visible()).To(BeEmpty())
+
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "b", "uid-b", nil)), nil)
+	model.Apply(model.Fetch())
+	g.Expect(names(model.Visible())).To(Equal([]string{"b"}))
+}
+
+func Test_Model_clone(t *testing.T) {
+	g := NewWithT(t)
+
+	fake := new(fakeclient.FakeFlintlockClient)
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "a", "uid-a", nil)), nil)
+	fake.CreateReturns(fixtures.CreateResponse(mvm("ns1", "a-copy", "uid-b", nil)), nil)
+
+	model := tui.New([]tui.Host{{"host1:9090", fake}}, &bytes.Buffer{}, "")
+	model.Refresh()
+
+	typeKeys(model, "c")
+	g.Expect(model.View(80, 10)).To(ContainSubstring("Clone as: a-clone"))
+
+	for range "clone" {
+		model.Update(tui.KeyBackspace)
+	}
+
+	typeKeys(model, "copy")
+	model.Update(tui.KeyEnter)
+	g.Expect(fake.CreateCallCount()).To(BeZero())
+	g.Expect(model.Status()).To(Equal("Cloning ns1/a uid-a as a-copy..."))
+
+	run(model)
+	g.Expect(fake.CreateCallCount()).To(Equal(1))
+
+	spec := fake.CreateArgsForCall(0)
+	g.Expect(spec.Id).To(Equal("a-copy"))
+	g.Expect(spec.Namespace).To(Equal("ns1"))
+	g.Expect(spec.Uid).To(BeNil())
+	g.Expect(model.Status()).To(Equal("Created ns1/a-copy uid-b"))
+
+	typeKeys(model, "c")
+	model.Update(tui.KeyEsc)
+	g.Expect(model.Actions()).To(BeEmpty())
+	g.Expect(fake.CreateCallCount()).To(Equal(1))
+	g.Expect(model.Status()).To(Equal("Clone cancelled"))
+}
+
+func Test_Model_copyUID(t *testing.T) {
+	g := NewWithT(t)
+
+	fake := new(fakeclient.FakeFlintlockClient)
+	fake.ListReturns(fixtures.ListResponse(mvm("ns1", "a", "uid-a", nil)), nil)
+
+	clipboard := &bytes.Buffer{}
+
+	model := tui.New([]tui.Host{{"host1:9090", fake}}, clipboard, "")
+	model.Refresh()
+
+	typeKeys(model, "y")
+	g.Expect(clipboard.String()).To(Equal("\x1b]52;c;dWlkLWE=\a"))
+	g.Expect(model.Status()).To(Equal("Copied uid-a to the clipboard"))
+}
+
+func typeKeys(model *tui.Model, keys string) {
+	for _, r := range keys {
+		model.Update(tui.Key(string(r)))
+	}
+}
+
+// run runs the Actions started by key presses, as Run would in the
+// background.
+func run(model *tui.Model) {
+	for _, action := range model.Actions() {
+		model.Finish(action())
+	}
+}
+
+func names(items []tui.Item) []string {
+	out := []string{}
+	for _, item := range items {
+		out = append(out, item.MicroVM.Spec.Id)
+	}
+
+	return out
+}
+
+func mvm(ns, name, uid string, labels map[string]string) *types.MicroVM {
+	return fixtures.MicroVM(ns, name, uid,
+		fixtures.WithLabels(labels),
+		fixtures.WithState(types.MicroVMStatus_CREATED),
+		fixtures.WithMetadata(map[string]string{"meta-data": "bG9jYWxfaG9zdG5hbWU6IGEK"}),
+	)
+}
@@ -0,0 +1,125 @@
+package tui
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const (
+	// Switch to the alternate screen and hide the cursor, and back again.
+	enterScreen = "\x1b[?1049h\x1b[?25l"
+	leaveScreen = "\x1b[?25h\x1b[?1049l"
+
+	home       = "\x1b[H"
+	clearLine  = "\x1b[K"
+	clearBelow = "\x1b[J"
+
+	defaultWidth  = 80
+	defaultHeight = 24
+)
+
+// Run draws the model full screen on the terminal and handles key presses
+// until the user quits. The Microvms are listed again every refresh, and the
+// Actions keys start are run, in the background so that the ui stays
+// responsive.
+func Run(model *Model, in, out *os.File, refresh time.Duration) error {
+	fd := int(in.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("the ui must be run in a terminal")
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return err
+	}
+
+	defer term.Restore(fd, state) //nolint: errcheck // nothing more can be done
+
+	fmt.Fprint(out, enterScreen)
+	defer fmt.Fprint(out, leaveScreen)
+
+	keys := make(chan []Key)
+	go readKeys(in, keys)
+
+	snapshots := make(chan Snapshot, 1)
+	fetch := func() {
+		go func() { snapshots <- model.Fetch() }()
+	}
+
+	fetch()
+
+	fetching := true
+
+	results := make(chan Result)
+
+	ticker := time.NewTicker(refresh)
+	defer ticker.Stop()
+
+	for {
+		draw(out, model)
+
+		select {
+		case batch, ok := <-keys:
+			if !ok {
+				return nil
+			}
+
+			for _, key := range batch {
+				if model.Update(key) {
+					return nil
+				}
+			}
+
+			for _, action := range model.Actions() {
+				go func(action Action) { results <- action() }(action)
+			}
+		case result := <-results:
+			model.Finish(result)
+		case snapshot := <-snapshots:
+			model.Apply(snapshot)
+
+			fetching = false
+		case <-ticker.C:
+			if !fetching {
+				fetching = true
+
+				fetch()
+			}
+		}
+	}
+}
+
+func readKeys(in io.Reader, keys chan<- []Key) {
+	defer close(keys)
+
+	buf := make([]byte, 256) //nolint: gomnd // plenty for a burst of key presses
+
+	for {
+		n, err := in.Read(buf)
+		if n > 0 {
+			keys <- ParseKeys(buf[:n])
+		}
+
+		if err != nil {
+			return
+		}
+	}
+}
+
+func draw(out *os.File, model *Model) {
+	width, height, err := term.GetSize(int(out.Fd()))
+	if err != nil {
+		width, height = defaultWidth, defaultHeight
+	}
+
+	view := model.View(width, height)
+
+	// The terminal is in raw mode, so newlines do not return the cursor.
+	fmt.Fprint(out, home+strings.ReplaceAll(view, "\n", clearLine+"\r\n")+clearLine+clearBelow)
+}
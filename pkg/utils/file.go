@@ -9,10 +9,11 @@ import (
 )
 
 // ProcessFile will open the given file and process the JSON into a MicroVMSpec.
-func ProcessFile(file string) (string, string, string, error) {
+// The file is rendered as a template with the given values first.
+func ProcessFile(file string, values map[string]interface{}) (string, string, string, error) {
 	var uid, name, namespace string
 
-	spec, err := LoadSpecFromFile(file)
+	spec, err := LoadSpecFromFile(file, values)
 	if err != nil {
 		return "", "", "", err
 	}
@@ -31,12 +32,19 @@ func ProcessFile(file string) (string, string, string, error) {
 	return uid, name, namespace, nil
 }
 
-func LoadSpecFromFile(file string) (*types.MicroVMSpec, error) {
+// LoadSpecFromFile renders the given file as a template with the given values
+// (see RenderTemplate) and processes the resulting JSON into a MicroVMSpec.
+func LoadSpecFromFile(file string, values map[string]interface{}) (*types.MicroVMSpec, error) {
 	dat, err := os.ReadFile(file)
 	if err != nil {
 		return nil, err
 	}
 
+	dat, err = RenderTemplate(file, dat, values)
+	if err != nil {
+		return nil, err
+	}
+
 	var spec *types.MicroVMSpec
 	if err := json.Unmarshal(dat, &spec); err != nil {
 		return nil, err
@@ -103,7 +103,7 @@ func Test_ProcessFile(t *testing.T) {
 
 			g.Expect(ioutil.WriteFile(tempFile.Name(), dat, 0755)).To(Succeed())
 
-			outUid, outN, outNs, err := utils.ProcessFile(tc.filename)
+			outUid, outN, outNs, err := utils.ProcessFile(tc.filename, nil)
 
 			out := testData{outN, outNs, &outUid}
 
@@ -166,7 +166,7 @@ func Test_LoadSpecFromFile(t *testing.T) {
 		t.Run(tc.test, func(t *testing.T) {
 			g.Expect(ioutil.WriteFile(tempFile.Name(), []byte(tc.input), 0755)).To(Succeed())
 
-			out, err := utils.LoadSpecFromFile(tc.filename)
+			out, err := utils.LoadSpecFromFile(tc.filename, nil)
 			tc.expected(g, out, err)
 		})
 	}
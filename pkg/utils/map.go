@@ -0,0 +1,42 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StringKeys recursively converts the map[interface{}]interface{} values
+// produced by yaml.v2 into map[string]interface{} so that they can be
+// marshalled to JSON or walked by key.
+func StringKeys(in interface{}) interface{} {
+	switch val := in.(type) {
+	case map[interface{}]interface{}:
+		out := map[string]interface{}{}
+		for k, v := range val {
+			out[fmt.Sprint(k)] = StringKeys(v)
+		}
+
+		return out
+	case []interface{}:
+		for i, v := range val {
+			val[i] = StringKeys(v)
+		}
+
+		return val
+	default:
+		return in
+	}
+}
+
+// HasLabel reports whether labels match the selector, which is either `key`
+// (any value) or `key=value`.
+func HasLabel(labels map[string]string, selector string) bool {
+	key, value, withValue := strings.Cut(selector, "=")
+
+	got, ok := labels[key]
+	if !ok {
+		return false
+	}
+
+	return !withValue || got == value
+}
@@ -42,3 +42,16 @@ func (w Writer) PrettyPrint(response interface{}) error {
 
 	return nil
 }
+
+// CompactPrint will write the given object to the out writer as JSON on a
+// single line, for newline delimited JSON streams.
+func (w Writer) CompactPrint(response interface{}) error {
+	resJSON, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w.out, "%s\n", string(resJSON))
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+// IsTerminal returns true if the file is a terminal, eg. when stdin has not
+// been redirected.
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// Confirm writes the question to the Writer and reads the answer from in. Only
+// `y` or `yes` (in any case) count as confirmation.
+func Confirm(w Writer, in io.Reader, question string) (bool, error) {
+	w.Printf("%s [y/N]: ", question)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return false, err
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
@@ -0,0 +1,52 @@
+package utils_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_Confirm(t *testing.T) {
+	tt := []struct {
+		name   string
+		answer string
+		want   bool
+	}{
+		{name: "yes", answer: "yes\n", want: true},
+		{name: "y, any case, no newline", answer: " Y ", want: true},
+		{name: "no", answer: "no\n", want: false},
+		{name: "empty", answer: "", want: false},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			buf := &bytes.Buffer{}
+
+			ok, err := utils.Confirm(utils.NewWriter(buf), strings.NewReader(tc.answer), "Continue?")
+			g.Expect(err).NotTo(HaveOccurred())
+			g.Expect(ok).To(Equal(tc.want))
+			g.Expect(buf.String()).To(Equal("Continue? [y/N]: "))
+		})
+	}
+}
+
+func Test_IsTerminal(t *testing.T) {
+	g := NewWithT(t)
+
+	r, w, err := os.Pipe()
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Cleanup(func() {
+		r.Close()
+		w.Close()
+	})
+
+	g.Expect(utils.IsTerminal(r)).To(BeFalse())
+}
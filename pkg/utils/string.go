@@ -1,6 +1,27 @@
 package utils
 
+import (
+	"fmt"
+	"strings"
+)
+
 // IsSet returns true if the value of flag is not empty.
 func IsSet(flag string) bool {
 	return flag != ""
 }
+
+// ParseKeyValues parses a list of `key=value` pairs into a map.
+func ParseKeyValues(pairs []string) (map[string]string, error) {
+	out := map[string]string{}
+
+	for _, pair := range pairs {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || !IsSet(key) {
+			return nil, fmt.Errorf("invalid key=value pair: %q", pair)
+		}
+
+		out[key] = value
+	}
+
+	return out, nil
+}
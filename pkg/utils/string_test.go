@@ -0,0 +1,27 @@
+package utils_test
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_ParseKeyValues(t *testing.T) {
+	g := NewWithT(t)
+
+	out, err := utils.ParseKeyValues([]string{"foo=bar", "baz=a=b", "empty="})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(out).To(Equal(map[string]string{
+		"foo":   "bar",
+		"baz":   "a=b",
+		"empty": "",
+	}))
+
+	_, err = utils.ParseKeyValues([]string{"foo"})
+	g.Expect(err).To(MatchError(ContainSubstring("invalid key=value pair")))
+
+	_, err = utils.ParseKeyValues([]string{"=bar"})
+	g.Expect(err).To(HaveOccurred())
+}
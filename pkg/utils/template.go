@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v2"
+)
+
+var (
+	// templateVariable matches `${NAME}` references. `$${NAME}` escapes a
+	// reference.
+	templateVariable = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_.-]*)\}`)
+
+	goTemplateDelim = []byte("{{")
+)
+
+// LoadTemplateValues builds the values to render a template with. Values are
+// read from the YAML file at valuesFile (if set), then from the list of
+// `key=value` sets, which take precedence. Dotted keys in sets (eg.
+// `labels.env=lab`) are nested.
+func LoadTemplateValues(valuesFile string, sets []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+
+	if IsSet(valuesFile) {
+		data, err := os.ReadFile(valuesFile)
+		if err != nil {
+			return nil, err
+		}
+
+		raw := map[interface{}]interface{}{}
+		if err := yaml.Unmarshal(data, &raw); err != nil {
+			return nil, fmt.Errorf("parsing values file %s: %w", valuesFile, err)
+		}
+
+		values = StringKeys(raw).(map[string]interface{})
+	}
+
+	pairs, err := ParseKeyValues(sets)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range pairs {
+		setPath(values, strings.Split(key, "."), value)
+	}
+
+	return values, nil
+}
+
+// RenderTemplate expands the template data with the given values. Documents containing
+// `{{` are Go templates, where values are available as `.key` and environment
+// variables through `env "NAME"`. Other documents have `${NAME}` references
+// substituted from the values (using dotted paths for nested keys) or, failing
+// that, the environment. Only one engine is used per document, so `${...}` in a
+// Go template, or in the values it renders, is left as it is.
+//
+// Referencing a variable which is not set is an error.
+func RenderTemplate(name string, data []byte, values map[string]interface{}) ([]byte, error) {
+	if !bytes.Contains(data, goTemplateDelim) {
+		return substituteVariables(name, data, values)
+	}
+
+	tmpl, err := template.New(name).
+		Option("missingkey=error").
+		Funcs(template.FuncMap{"env": templateEnv}).
+		Parse(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template %s: %w", name, err)
+	}
+
+	out := &bytes.Buffer{}
+	if err := tmpl.Execute(out, values); err != nil {
+		return nil, fmt.Errorf("rendering template %s: %w", name, err)
+	}
+
+	return out.Bytes(), nil
+}
+
+func substituteVariables(name string, data []byte, values map[string]interface{}) ([]byte, error) {
+	missing := map[string]bool{}
+
+	out := templateVariable.ReplaceAllFunc(data, func(match []byte) []byte {
+		if bytes.HasPrefix(match, []byte("$$")) {
+			return match[1:]
+		}
+
+		key := string(templateVariable.FindSubmatch(match)[1])
+
+		if value, ok := lookupPath(values, strings.Split(key, ".")); ok {
+			return []byte(fmt.Sprint(value))
+		}
+
+		if value, ok := os.LookupEnv(key); ok {
+			return []byte(value)
+		}
+
+		missing[key] = true
+
+		return match
+	})
+
+	if len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for key := range missing {
+			names = append(names, key)
+		}
+
+		sort.Strings(names)
+
+		return nil, fmt.Errorf(
+			"rendering template %s: undefined variables: %s (use --set, --values or the environment)",
+			name, strings.Join(names, ", "),
+		)
+	}
+
+	return out, nil
+}
+
+func templateEnv(key string) (string, error) {
+	value, ok := os.LookupEnv(key)
+	if !ok {
+		return "", fmt.Errorf("environment variable %s is not set", key)
+	}
+
+	return value, nil
+}
+
+func lookupPath(values map[string]interface{}, path []string) (interface{}, bool) {
+	value, ok := values[path[0]]
+	if !ok || len(path) == 1 {
+		return value, ok
+	}
+
+	nested, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	return lookupPath(nested, path[1:])
+}
+
+func setPath(values map[string]interface{}, path []string, value string) {
+	if len(path) == 1 {
+		values[path[0]] = value
+
+		return
+	}
+
+	nested, ok := values[path[0]].(map[string]interface{})
+	if !ok {
+		nested = map[string]interface{}{}
+		values[path[0]] = nested
+	}
+
+	setPath(nested, path[1:], value)
+}
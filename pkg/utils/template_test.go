@@ -0,0 +1,120 @@
+package utils_test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/warehouse-13/hammertime/pkg/utils"
+)
+
+func Test_LoadTemplateValues(t *testing.T) {
+	g := NewWithT(t)
+
+	tempFile, err := ioutil.TempFile("", "values_test")
+	g.Expect(err).NotTo(HaveOccurred())
+
+	t.Cleanup(func() {
+		g.Expect(os.RemoveAll(tempFile.Name())).To(Succeed())
+	})
+
+	g.Expect(ioutil.WriteFile(tempFile.Name(), []byte("name: foo\nlabels:\n  env: dev\n  team: a\n"), 0755)).To(Succeed())
+
+	values, err := utils.LoadTemplateValues(tempFile.Name(), []string{"labels.env=lab", "ip=10.0.0.1"})
+	g.Expect(err).NotTo(HaveOccurred())
+	g.Expect(values).To(Equal(map[string]interface{}{
+		"name": "foo",
+		"ip":   "10.0.0.1",
+		"labels": map[string]interface{}{
+			"env":  "lab",
+			"team": "a",
+		},
+	}))
+
+	_, err = utils.LoadTemplateValues("noexist", nil)
+	g.Expect(err).To(HaveOccurred())
+
+	_, err = utils.LoadTemplateValues("", []string{"novalue"})
+	g.Expect(err).To(HaveOccurred())
+}
+
+func Test_RenderTemplate(t *testing.T) {
+	t.Setenv("HT_TEST_NS", "envns")
+
+	values := map[string]interface{}{
+		"name":   "foo",
+		"labels": map[string]interface{}{"env": "lab"},
+		"raw":    "${name}",
+	}
+
+	tt := []struct {
+		test     string
+		input    string
+		expected func(*WithT, string, error)
+	}{
+		{
+			test:  "substitutes variables from values and the environment",
+			input: `{"id": "${name}", "namespace": "${HT_TEST_NS}", "env": "${labels.env}"}`,
+			expected: func(g *WithT, out string, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(out).To(Equal(`{"id": "foo", "namespace": "envns", "env": "lab"}`))
+			},
+		},
+		{
+			test:  "renders go templates",
+			input: `{"id": "{{ .name }}", "namespace": "{{ env "HT_TEST_NS" }}", "env": "{{ .labels.env }}"}`,
+			expected: func(g *WithT, out string, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(out).To(Equal(`{"id": "foo", "namespace": "envns", "env": "lab"}`))
+			},
+		},
+		{
+			test:  "does not substitute variables in go templates",
+			input: `{"id": "{{ .name }}", "script": "echo ${HOME}", "quoted": "{{ .raw }}"}`,
+			expected: func(g *WithT, out string, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(out).To(Equal(`{"id": "foo", "script": "echo ${HOME}", "quoted": "${name}"}`))
+			},
+		},
+		{
+			test:  "leaves escaped and non-braced variables alone",
+			input: `{"a": "$${name}", "b": "$UPTIME"}`,
+			expected: func(g *WithT, out string, err error) {
+				g.Expect(err).NotTo(HaveOccurred())
+				g.Expect(out).To(Equal(`{"a": "${name}", "b": "$UPTIME"}`))
+			},
+		},
+		{
+			test:  "when variables are missing, returns an error naming them",
+			input: `{"id": "${nope}", "namespace": "${also_nope}"}`,
+			expected: func(g *WithT, out string, err error) {
+				g.Expect(err).To(MatchError(ContainSubstring("undefined variables: also_nope, nope")))
+			},
+		},
+		{
+			test:  "when go template keys are missing, returns an error",
+			input: `{"id": "{{ .nope }}"}`,
+			expected: func(g *WithT, out string, err error) {
+				g.Expect(err).To(MatchError(ContainSubstring(`no entry for key "nope"`)))
+			},
+		},
+		{
+			test:  "when environment variables are missing, returns an error",
+			input: `{"id": "{{ env "HT_TEST_NOPE" }}"}`,
+			expected: func(g *WithT, out string, err error) {
+				g.Expect(err).To(MatchError(ContainSubstring("HT_TEST_NOPE is not set")))
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.test, func(t *testing.T) {
+			g := NewWithT(t)
+
+			out, err := utils.RenderTemplate("spec.json", []byte(tc.input), values)
+			tc.expected(g, string(out), err)
+		})
+	}
+}
@@ -8,7 +8,6 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
-	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 	"github.com/warehouse-13/safety"
 )
@@ -37,12 +36,12 @@ func TestIntegration(t *testing.T) {
 			address = fakeserver.Start("")
 		}
 
-		// Sometimes the server doesn't start immediately, so we check that an
-		// endpoint is reachable before we carry on with the test.
-		getCmd := command{action: "get", args: []string{"--id", "foobar"}}
-		getSession := executeCommand(getCmd)
-		Eventually(getSession, "10s").Should(gexec.Exit(1))
-		Eventually(getSession.Err).Should(gbytes.Say("rpc error"))
+		// Sometimes the server doesn't start immediately, so we check that it is
+		// reachable before we carry on with the test.
+		Eventually(func(g Gomega) {
+			pingSession := executeCommand(command{action: "ping"})
+			g.Expect(pingSession.Wait("10s")).To(gexec.Exit(0))
+		}, "30s", "1s").Should(Succeed())
 	})
 
 	AfterSuite(func() {
@@ -10,6 +10,8 @@ import (
 	"github.com/onsi/gomega/gexec"
 	"github.com/weaveworks-liquidmetal/flintlock/api/services/microvm/v1alpha1"
 	"github.com/weaveworks-liquidmetal/flintlock/api/types"
+
+	"github.com/warehouse-13/hammertime/pkg/exitcode"
 )
 
 const (
@@ -33,7 +35,7 @@ var _ = Describe("Integration", func() {
 	})
 
 	AfterEach(func() {
-		_ = delete("--all")
+		Eventually(delete("--all", "--yes"), timeout).Should(gexec.Exit(0))
 	})
 
 	It("creating a MicroVM", func() {
@@ -67,8 +69,8 @@ var _ = Describe("Integration", func() {
 
 		Eventually(func(g Gomega) {
 			session := get("--id", *created1.Microvm.Spec.Uid)
-			g.Expect(session.Wait()).To(gexec.Exit(1))
-			g.Expect(session.Err).To(gbytes.Say("rpc error"))
+			g.Expect(session.Wait()).To(gexec.Exit(exitcode.NotFound))
+			g.Expect(session.Err).To(gbytes.Say("Error: MicroVM " + *created1.Microvm.Spec.Uid + " not found"))
 		}, timeout, interval).Should(Succeed())
 
 		Expect(listAll()).To(Equal(0))